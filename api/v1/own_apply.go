@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// server-side apply 使用的field manager，own resource中只有Unit指定的字段归它管理
+const UnitFieldManager string = "unit-controller"
+
+// 通过server-side apply 创建/更新own resource。
+// 只提交Unit关心的字段，apiServer填充的默认值以及其它controller(如HPA)管理的字段不会被覆盖；
+// 对象没有变化时apiServer不会产生写入，resourceVersion保持不变，此时不记录Event。
+// found为OwnResourceExist查到的已有对象，不存在时为nil
+func applyOwnResource(instance *Unit, c client.Client, logger logr.Logger, scheme *runtime.Scheme,
+	recorder record.EventRecorder, kind string, obj runtime.Object, found interface{}) error {
+
+	// apply请求必须带上apiVersion/kind
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	oldResourceVersion := ""
+	if found != nil {
+		if foundAccessor, err := meta.Accessor(found); err == nil {
+			oldResourceVersion = foundAccessor.GetResourceVersion()
+		}
+	} else {
+		msg := fmt.Sprintf("%s %s/%s not found, create it!", kind, accessor.GetNamespace(), accessor.GetName())
+		logger.Info(msg)
+	}
+
+	err = c.Patch(context.TODO(), obj, client.Apply, client.FieldOwner(UnitFieldManager), client.ForceOwnership)
+	if err != nil {
+		action := "apply"
+		if found == nil {
+			action = "create"
+		}
+		recordOwnResourceEvent(instance, recorder, action, EventReasonFailed, kind, obj, err)
+		return err
+	}
+
+	switch {
+	case found == nil:
+		recordOwnResourceEvent(instance, recorder, "create", EventReasonCreated, kind, obj, nil)
+	case accessor.GetResourceVersion() != oldResourceVersion:
+		msg := fmt.Sprintf("Updated %s %s/%s", kind, accessor.GetNamespace(), accessor.GetName())
+		logger.Info(msg)
+		recordOwnResourceEvent(instance, recorder, "update", EventReasonUpdated, kind, obj, nil)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sort"
+)
+
+type OwnCronJob struct {
+	Spec batchv1beta1.CronJobSpec `json:"spec"`
+}
+
+func (ownCronJob *OwnCronJob) MakeOwnResource(instance *Unit, logger logr.Logger,
+	scheme *runtime.Scheme) (interface{}, error) {
+
+	// new a CronJob object
+	cronJob := &batchv1beta1.CronJob{
+		// metadata field inherited from owner Unit
+		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace, Labels: instance.Labels},
+		Spec:       ownCronJob.Spec,
+	}
+
+	// add ControllerReference for cronJob，the owner is Unit object
+	if err := controllerutil.SetControllerReference(instance, cronJob, scheme); err != nil {
+		msg := fmt.Sprintf("set controllerReference for CronJob %s/%s failed", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return nil, err
+	}
+
+	return cronJob, nil
+}
+
+// Check if the CronJob already exists
+func (ownCronJob *OwnCronJob) OwnResourceExist(instance *Unit, client client.Client,
+	logger logr.Logger) (bool, interface{}, error) {
+
+	found := &batchv1beta1.CronJob{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		msg := fmt.Sprintf("CronJob %s/%s found, but with error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return true, found, err
+	}
+	return true, found, nil
+}
+
+func (ownCronJob *OwnCronJob) UpdateOwnResourceStatus(instance *Unit, client client.Client,
+	logger logr.Logger) (*Unit, error) {
+
+	found := &batchv1beta1.CronJob{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		return instance, err
+	}
+	instance.Status.BaseCronJob = found.Status
+
+	// CronJob 调度产生的Job，即为Unit的执行历史
+	history, err := listCronJobHistory(client, found)
+	if err != nil {
+		msg := fmt.Sprintf("list CronJob %s/%s jobs error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return instance, err
+	}
+	instance.Status.JobHistory = history
+	instance.Status.LastUpdateTime = metav1.Now()
+	return instance, nil
+}
+
+// apply this own resource, create or update
+func (ownCronJob *OwnCronJob) ApplyOwnResource(instance *Unit, client client.Client,
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
+
+	// assert if CronJob exist
+	_, found, err := ownCronJob.OwnResourceExist(instance, client, logger)
+	if err != nil {
+		return err
+	}
+
+	// make CronJob object
+	cronJob, err := ownCronJob.MakeOwnResource(instance, logger, scheme)
+	if err != nil {
+		return err
+	}
+	newCronJob := cronJob.(*batchv1beta1.CronJob)
+
+	// apply the CronJob object just make，通过server-side apply 创建或更新，只管理Unit指定的字段
+	return applyOwnResource(instance, client, logger, scheme, recorder, "CronJob", newCronJob, found)
+}
+
+// 列出CronJob调度产生的所有Job，按开始时间倒序生成执行记录。
+// Job的label来自jobTemplate的label；jobTemplate未指定label时，apiServer会以pod模板的label作为Job的label
+func listCronJobHistory(c client.Client, cronJob *batchv1beta1.CronJob) ([]UnitJobStatus, error) {
+	jobList := &batchv1.JobList{}
+	opts := []client.ListOption{client.InNamespace(cronJob.Namespace)}
+	labels := cronJob.Spec.JobTemplate.Labels
+	if len(labels) == 0 {
+		labels = cronJob.Spec.JobTemplate.Spec.Template.Labels
+	}
+	if len(labels) > 0 {
+		opts = append(opts, client.MatchingLabels(labels))
+	}
+	if err := c.List(context.TODO(), jobList, opts...); err != nil {
+		return nil, err
+	}
+
+	var history []UnitJobStatus
+	for index := range jobList.Items {
+		job := &jobList.Items[index]
+		owner := metav1.GetControllerOf(job)
+		if owner == nil || owner.UID != cronJob.UID {
+			continue
+		}
+		history = append(history, makeJobHistory(job))
+	}
+
+	// 还未开始的Job视为最新的
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].StartTime == nil || history[j].StartTime == nil {
+			return history[i].StartTime == nil && history[j].StartTime != nil
+		}
+		return history[j].StartTime.Before(history[i].StartTime)
+	})
+	return history, nil
+}
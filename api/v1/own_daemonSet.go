@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+type OwnDaemonSet struct {
+	Spec appsv1.DaemonSetSpec `json:"spec"`
+}
+
+func (ownDaemonSet *OwnDaemonSet) MakeOwnResource(instance *Unit, logger logr.Logger,
+	scheme *runtime.Scheme) (interface{}, error) {
+
+	// new a DaemonSet object
+	ds := &appsv1.DaemonSet{
+		// metadata field inherited from owner Unit
+		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace, Labels: instance.Labels},
+		Spec:       ownDaemonSet.Spec,
+	}
+
+	// add ControllerReference for ds，the owner is Unit object
+	if err := controllerutil.SetControllerReference(instance, ds, scheme); err != nil {
+		msg := fmt.Sprintf("set controllerReference for DaemonSet %s/%s failed", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return nil, err
+	}
+
+	return ds, nil
+}
+
+// Check if the DaemonSet already exists
+func (ownDaemonSet *OwnDaemonSet) OwnResourceExist(instance *Unit, client client.Client,
+	logger logr.Logger) (bool, interface{}, error) {
+
+	found := &appsv1.DaemonSet{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		msg := fmt.Sprintf("DaemonSet %s/%s found, but with error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return true, found, err
+	}
+	return true, found, nil
+}
+
+func (ownDaemonSet *OwnDaemonSet) UpdateOwnResourceStatus(instance *Unit, client client.Client,
+	logger logr.Logger) (*Unit, error) {
+
+	found := &appsv1.DaemonSet{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		return instance, err
+	}
+	instance.Status.BaseDaemonSet = found.Status
+	instance.Status.LastUpdateTime = metav1.Now()
+	return instance, nil
+}
+
+// apply this own resource, create or update
+func (ownDaemonSet *OwnDaemonSet) ApplyOwnResource(instance *Unit, client client.Client,
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
+
+	// assert if DaemonSet exist
+	_, found, err := ownDaemonSet.OwnResourceExist(instance, client, logger)
+	if err != nil {
+		return err
+	}
+
+	// make DaemonSet object
+	ds, err := ownDaemonSet.MakeOwnResource(instance, logger, scheme)
+	if err != nil {
+		return err
+	}
+	newDaemonSet := ds.(*appsv1.DaemonSet)
+
+	// apply the DaemonSet object just make，通过server-side apply 创建或更新，只管理Unit指定的字段
+	return applyOwnResource(instance, client, logger, scheme, recorder, "DaemonSet", newDaemonSet, found)
+}
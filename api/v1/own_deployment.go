@@ -5,12 +5,11 @@ import (
 	"fmt"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
-	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"reflect"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -30,34 +29,6 @@ func (ownDeployment *OwnDeployment) MakeOwnResource(instance *Unit, logger logr.
 		Spec:       ownDeployment.Spec,
 	}
 
-	//deployment.Spec.Template.Labels
-	// add some customize envs, ignore this step if you don't need it
-	customizeEnvs := []v1.EnvVar{
-		{
-			Name: "POD_NAME",
-			ValueFrom: &v1.EnvVarSource{
-				FieldRef: &v1.ObjectFieldSelector{
-					APIVersion: "v1",
-					FieldPath:  "metadata.name",
-				},
-			},
-		},
-		{
-			Name:  "APPNAME",
-			Value: instance.Name,
-		},
-	}
-
-	var specEnvs []v1.EnvVar
-	templateEnvs := deployment.Spec.Template.Spec.Containers[0].Env
-	for index := range templateEnvs {
-		if templateEnvs[index].Name != "POD_NAME" && templateEnvs[index].Name != "APPNAME" {
-			specEnvs = append(specEnvs, templateEnvs[index])
-		}
-	}
-
-	deployment.Spec.Template.Spec.Containers[0].Env = append(specEnvs, customizeEnvs...)
-
 	// add ControllerReference for deployment，the owner is Unit object
 	if err := controllerutil.SetControllerReference(instance, deployment, scheme); err != nil {
 		msg := fmt.Sprintf("set controllerReference for Deployment %s/%s failed", instance.Namespace, instance.Name)
@@ -98,6 +69,11 @@ func (ownDeployment *OwnDeployment) UpdateOwnResourceStatus(instance *Unit, clie
 
 	// 将deployment的状态更新到Unit.status.deployment中
 	instance.Status.BaseDeployment = found.Status
+	if err := setScaleStatus(instance, found.Status.Replicas, found.Spec.Selector); err != nil {
+		msg := fmt.Sprintf("convert Deployment %s/%s selector error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return instance, err
+	}
 	instance.Status.LastUpdateTime = metav1.Now()
 
 	return instance, nil
@@ -105,7 +81,7 @@ func (ownDeployment *OwnDeployment) UpdateOwnResourceStatus(instance *Unit, clie
 
 // apply this own resource, create or update
 func (ownDeployment *OwnDeployment) ApplyOwnResource(instance *Unit, client client.Client,
-	logger logr.Logger, scheme *runtime.Scheme) error {
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
 	// make deployment object
 	deployment, err := ownDeployment.MakeOwnResource(instance, logger, scheme)
 	if err != nil {
@@ -114,29 +90,11 @@ func (ownDeployment *OwnDeployment) ApplyOwnResource(instance *Unit, client clie
 	newDeployment := deployment.(*appsv1.Deployment)
 
 	// assert if deployment already exist
-	exist, found, err := ownDeployment.OwnResourceExist(instance, client, logger)
+	_, found, err := ownDeployment.OwnResourceExist(instance, client, logger)
 	if err != nil {
 		return err
 	}
 
-	// apply the deployment object just make
-	if !exist {
-		// if deployment not exist，then create it
-		msg := fmt.Sprintf("Deployment %s/%s not found, create it!", newDeployment.Namespace, newDeployment.Name)
-		logger.Info(msg)
-		if err := client.Create(context.TODO(), newDeployment); err != nil {
-			return err
-		}
-		return nil
-
-	} else {
-		foundDeployment := found.(*appsv1.Deployment)
-		// if deployment exist with change，then try to update it
-		if !reflect.DeepEqual(newDeployment.Spec, foundDeployment.Spec) {
-			msg := fmt.Sprintf("Updating Deployment %s/%s", newDeployment.Namespace, newDeployment.Name)
-			logger.Info(msg)
-			return client.Update(context.TODO(), newDeployment)
-		}
-		return nil
-	}
+	// apply the deployment object just make，通过server-side apply 创建或更新，只管理Unit指定的字段
+	return applyOwnResource(instance, client, logger, scheme, recorder, "Deployment", newDeployment, found)
 }
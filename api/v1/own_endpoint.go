@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"context"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+)
+
+// EndpointSlice的API版本，controller不依赖具体版本的Go类型，统一使用unstructured对象
+const (
+	EndpointSliceAPIVersionV1      string = "discovery.k8s.io/v1"
+	EndpointSliceAPIVersionV1beta1 string = "discovery.k8s.io/v1beta1"
+
+	// EndpointSlice通过此label关联到Service
+	endpointSliceServiceNameLabel string = "kubernetes.io/service-name"
+)
+
+type UnitEndpointPortStatus struct {
+	Name     string      `json:"name,omitempty"`
+	Port     int32       `json:"port"`
+	Protocol v1.Protocol `json:"protocol,omitempty"`
+}
+
+type UnitRelationEndpointStatus struct {
+	// 来自endpoint的targetRef，没有targetRef时为hostname
+	PodName  string `json:"podName"`
+	PodIP    string `json:"podIP"`
+	NodeName string `json:"nodeName"`
+	// 未就绪的pod也会列出，ready为false
+	Ready bool                     `json:"ready"`
+	Ports []UnitEndpointPortStatus `json:"ports,omitempty"`
+}
+
+// 获取Service后端pod的状态，集群提供EndpointSlice时从EndpointSlice获取，否则从Endpoints获取
+func (ownService *OwnService) EndpointsStatus(instance *Unit, c client.Client) ([]UnitRelationEndpointStatus, error) {
+	var endpointsStatus []UnitRelationEndpointStatus
+	if ownService.EndpointSliceAPIVersion != "" {
+		sliceList := &unstructured.UnstructuredList{}
+		sliceList.SetAPIVersion(ownService.EndpointSliceAPIVersion)
+		sliceList.SetKind("EndpointSliceList")
+		err := c.List(context.TODO(), sliceList, client.InNamespace(instance.Namespace),
+			client.MatchingLabels{endpointSliceServiceNameLabel: ownService.ServiceName(instance)})
+		if err != nil {
+			return nil, err
+		}
+		for i := range sliceList.Items {
+			endpointsStatus = append(endpointsStatus, parseEndpointSlice(&sliceList.Items[i])...)
+		}
+	} else {
+		found := &v1.Endpoints{}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: ownService.ServiceName(instance), Namespace: instance.Namespace}, found)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		endpointsStatus = parseEndpoints(found)
+	}
+
+	// EndpointSlice/subsets中endpoint的顺序不固定，排序后避免status无意义的变化
+	sort.Slice(endpointsStatus, func(i, j int) bool {
+		if endpointsStatus[i].PodName != endpointsStatus[j].PodName {
+			return endpointsStatus[i].PodName < endpointsStatus[j].PodName
+		}
+		return endpointsStatus[i].PodIP < endpointsStatus[j].PodIP
+	})
+	return endpointsStatus, nil
+}
+
+// 解析Endpoints的所有subsets，包括notReadyAddresses
+func parseEndpoints(endpoints *v1.Endpoints) []UnitRelationEndpointStatus {
+	var endpointsStatus []UnitRelationEndpointStatus
+	for _, subset := range endpoints.Subsets {
+		var ports []UnitEndpointPortStatus
+		for _, port := range subset.Ports {
+			ports = append(ports, UnitEndpointPortStatus{Name: port.Name, Port: port.Port, Protocol: port.Protocol})
+		}
+		addresses := []struct {
+			items []v1.EndpointAddress
+			ready bool
+		}{
+			{subset.Addresses, true},
+			{subset.NotReadyAddresses, false},
+		}
+		for _, address := range addresses {
+			for _, ep := range address.items {
+				endpointStatus := UnitRelationEndpointStatus{
+					PodName: ep.Hostname,
+					PodIP:   ep.IP,
+					Ready:   address.ready,
+					Ports:   ports,
+				}
+				if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+					endpointStatus.PodName = ep.TargetRef.Name
+				}
+				if ep.NodeName != nil {
+					endpointStatus.NodeName = *ep.NodeName
+				}
+				endpointsStatus = append(endpointsStatus, endpointStatus)
+			}
+		}
+	}
+	return endpointsStatus
+}
+
+// 解析EndpointSlice，v1beta1中nodeName在较新的版本才有，老版本从topology中的hostname获取
+func parseEndpointSlice(slice *unstructured.Unstructured) []UnitRelationEndpointStatus {
+	var ports []UnitEndpointPortStatus
+	slicePorts, _, _ := unstructured.NestedSlice(slice.Object, "ports")
+	for _, item := range slicePorts {
+		port, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		portStatus := UnitEndpointPortStatus{}
+		portStatus.Name, _, _ = unstructured.NestedString(port, "name")
+		number, _, _ := unstructured.NestedInt64(port, "port")
+		portStatus.Port = int32(number)
+		protocol, _, _ := unstructured.NestedString(port, "protocol")
+		portStatus.Protocol = v1.Protocol(protocol)
+		ports = append(ports, portStatus)
+	}
+
+	var endpointsStatus []UnitRelationEndpointStatus
+	endpoints, _, _ := unstructured.NestedSlice(slice.Object, "endpoints")
+	for _, item := range endpoints {
+		endpoint, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// conditions.ready为空时视为就绪
+		ready, found, _ := unstructured.NestedBool(endpoint, "conditions", "ready")
+		if !found {
+			ready = true
+		}
+		podName, _, _ := unstructured.NestedString(endpoint, "hostname")
+		if kind, _, _ := unstructured.NestedString(endpoint, "targetRef", "kind"); kind == "Pod" {
+			podName, _, _ = unstructured.NestedString(endpoint, "targetRef", "name")
+		}
+		nodeName, found, _ := unstructured.NestedString(endpoint, "nodeName")
+		if !found {
+			nodeName, _, _ = unstructured.NestedString(endpoint, "topology", "kubernetes.io/hostname")
+		}
+
+		addresses, _, _ := unstructured.NestedStringSlice(endpoint, "addresses")
+		for _, address := range addresses {
+			endpointsStatus = append(endpointsStatus, UnitRelationEndpointStatus{
+				PodName:  podName,
+				PodIP:    address,
+				NodeName: nodeName,
+				Ready:    ready,
+				Ports:    ports,
+			})
+		}
+	}
+	return endpointsStatus
+}
+
+// 返回第一个就绪且提供此Service端口的endpoint的IP和端口号，endpoint的端口与Service端口按name和protocol对应
+func ReadyEndpointPort(endpoints []UnitRelationEndpointStatus, port v1.ServicePort) (string, int32, bool) {
+	protocol := port.Protocol
+	if protocol == "" {
+		protocol = v1.ProtocolTCP
+	}
+	for _, endpoint := range endpoints {
+		if !endpoint.Ready {
+			continue
+		}
+		for _, endpointPort := range endpoint.Ports {
+			endpointProtocol := endpointPort.Protocol
+			if endpointProtocol == "" {
+				endpointProtocol = v1.ProtocolTCP
+			}
+			if endpointPort.Name == port.Name && endpointProtocol == protocol {
+				return endpoint.PodIP, endpointPort.Port, true
+			}
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,147 @@
+package v1
+
+import (
+	"fmt"
+	v1 "k8s.io/api/core/v1"
+)
+
+// 可以注入到容器环境变量中的字段
+// +kubebuilder:validation:Enum=PodName;Namespace;NodeName;PodIP;UnitName;Revision
+type InjectEnvField string
+
+const (
+	InjectEnvPodName   InjectEnvField = "PodName"
+	InjectEnvNamespace InjectEnvField = "Namespace"
+	InjectEnvNodeName  InjectEnvField = "NodeName"
+	InjectEnvPodIP     InjectEnvField = "PodIP"
+	InjectEnvUnitName  InjectEnvField = "UnitName"
+	// 工作负载当前的版本，取自pod上workload controller添加的hash label
+	InjectEnvRevision InjectEnvField = "Revision"
+)
+
+// 未指定spec.injectEnv时注入的字段，与之前的行为保持一致
+var defaultInjectEnvFields = []InjectEnvField{InjectEnvPodName, InjectEnvUnitName}
+
+// 各字段注入时的环境变量名，实际的名称为 prefix + 此名称
+var injectEnvNames = map[InjectEnvField]string{
+	InjectEnvPodName:   "POD_NAME",
+	InjectEnvNamespace: "POD_NAMESPACE",
+	InjectEnvNodeName:  "NODE_NAME",
+	InjectEnvPodIP:     "POD_IP",
+	InjectEnvUnitName:  "APPNAME",
+	InjectEnvRevision:  "REVISION",
+}
+
+// 容器环境变量的注入配置
+type UnitInjectEnvSpec struct {
+	// 关闭环境变量注入
+	Disabled bool `json:"disabled,omitempty"`
+
+	// 注入的字段，默认为PodName和UnitName
+	Fields []InjectEnvField `json:"fields,omitempty"`
+
+	// 注入到哪些容器，为空时注入到所有容器
+	Containers []string `json:"containers,omitempty"`
+
+	// 是否同时注入到init容器，containers不为空时只注入其中指定的init容器
+	InitContainers bool `json:"initContainers,omitempty"`
+
+	// 环境变量名的前缀，例如 UNIT_ 会注入 UNIT_POD_NAME
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// 按spec.injectEnv将downward API字段注入到pod模板的容器中，模板中已有的同名环境变量会被覆盖
+func InjectEnv(instance *Unit, template *v1.PodTemplateSpec) {
+	injectEnv := instance.Spec.InjectEnv
+	if injectEnv == nil {
+		injectEnv = &UnitInjectEnvSpec{}
+	}
+	if injectEnv.Disabled {
+		return
+	}
+
+	envs := injectEnv.envVars(instance)
+	inject := func(containers []v1.Container) {
+		for i := range containers {
+			container := &containers[i]
+			if len(injectEnv.Containers) > 0 && !containsString(injectEnv.Containers, container.Name) {
+				continue
+			}
+
+			var specEnvs []v1.EnvVar
+			for _, env := range container.Env {
+				if !containsEnv(envs, env.Name) {
+					specEnvs = append(specEnvs, env)
+				}
+			}
+			container.Env = append(specEnvs, envs...)
+		}
+	}
+
+	inject(template.Spec.Containers)
+	if injectEnv.InitContainers {
+		inject(template.Spec.InitContainers)
+	}
+}
+
+// 生成需要注入的环境变量
+func (injectEnv *UnitInjectEnvSpec) envVars(instance *Unit) []v1.EnvVar {
+	fields := injectEnv.Fields
+	if len(fields) == 0 {
+		fields = defaultInjectEnvFields
+	}
+
+	fieldRef := func(fieldPath string) *v1.EnvVarSource {
+		return &v1.EnvVarSource{
+			FieldRef: &v1.ObjectFieldSelector{
+				APIVersion: "v1",
+				FieldPath:  fieldPath,
+			},
+		}
+	}
+
+	var envs []v1.EnvVar
+	for _, field := range fields {
+		env := v1.EnvVar{Name: injectEnv.Prefix + injectEnvNames[field]}
+		switch field {
+		case InjectEnvPodName:
+			env.ValueFrom = fieldRef("metadata.name")
+		case InjectEnvNamespace:
+			env.ValueFrom = fieldRef("metadata.namespace")
+		case InjectEnvNodeName:
+			env.ValueFrom = fieldRef("spec.nodeName")
+		case InjectEnvPodIP:
+			env.ValueFrom = fieldRef("status.podIP")
+		case InjectEnvUnitName:
+			env.Value = instance.Name
+		case InjectEnvRevision:
+			env.ValueFrom = fieldRef(fmt.Sprintf("metadata.labels['%s']", revisionLabel(instance.Spec.Category)))
+		default:
+			continue
+		}
+		envs = append(envs, env)
+	}
+	return envs
+}
+
+// 各类工作负载的controller在pod上记录版本的label
+func revisionLabel(category string) string {
+	switch category {
+	case CategoryDeployment:
+		return "pod-template-hash"
+	case CategoryJob, CategoryCronJob:
+		return "controller-uid"
+	default:
+		// StatefulSet / DaemonSet
+		return "controller-revision-hash"
+	}
+}
+
+func containsEnv(envs []v1.EnvVar, name string) bool {
+	for _, env := range envs {
+		if env.Name == name {
+			return true
+		}
+	}
+	return false
+}
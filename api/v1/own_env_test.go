@@ -0,0 +1,114 @@
+package v1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+// 返回容器中的环境变量名，downward API字段以 name=fieldPath 表示，其它以 name=value 表示
+func envSummary(container v1.Container) []string {
+	var summary []string
+	for _, env := range container.Env {
+		value := env.Value
+		if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil {
+			value = env.ValueFrom.FieldRef.FieldPath
+		}
+		summary = append(summary, env.Name+"="+value)
+	}
+	return summary
+}
+
+func TestInjectEnv(t *testing.T) {
+	newTemplate := func() *v1.PodTemplateSpec {
+		return &v1.PodTemplateSpec{
+			Spec: v1.PodSpec{
+				InitContainers: []v1.Container{{Name: "init"}},
+				Containers: []v1.Container{
+					{Name: "app", Env: []v1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}, {Name: "APPNAME", Value: "old"}}},
+					{Name: "sidecar"},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		name      string
+		category  string
+		injectEnv *UnitInjectEnvSpec
+		// 每个容器注入后的环境变量，按 init, app, sidecar 的顺序
+		want [][]string
+	}{
+		{
+			name:      "default fields to all containers",
+			injectEnv: nil,
+			want: [][]string{
+				nil,
+				{"LOG_LEVEL=info", "POD_NAME=metadata.name", "APPNAME=unit"},
+				{"POD_NAME=metadata.name", "APPNAME=unit"},
+			},
+		},
+		{
+			name:      "disabled",
+			injectEnv: &UnitInjectEnvSpec{Disabled: true},
+			want: [][]string{
+				nil,
+				{"LOG_LEVEL=info", "APPNAME=old"},
+				nil,
+			},
+		},
+		{
+			name:      "selected containers with init containers",
+			injectEnv: &UnitInjectEnvSpec{Fields: []InjectEnvField{InjectEnvNamespace}, Containers: []string{"init", "sidecar"}, InitContainers: true},
+			want: [][]string{
+				{"POD_NAMESPACE=metadata.namespace"},
+				{"LOG_LEVEL=info", "APPNAME=old"},
+				{"POD_NAMESPACE=metadata.namespace"},
+			},
+		},
+		{
+			name:      "prefix keeps existing env",
+			injectEnv: &UnitInjectEnvSpec{Fields: []InjectEnvField{InjectEnvUnitName, InjectEnvPodIP}, Prefix: "UNIT_", Containers: []string{"app"}},
+			want: [][]string{
+				nil,
+				{"LOG_LEVEL=info", "APPNAME=old", "UNIT_APPNAME=unit", "UNIT_POD_IP=status.podIP"},
+				nil,
+			},
+		},
+		{
+			name:      "statefulset revision",
+			category:  CategoryStatefulSet,
+			injectEnv: &UnitInjectEnvSpec{Fields: []InjectEnvField{InjectEnvRevision, InjectEnvNodeName}, Containers: []string{"sidecar"}},
+			want: [][]string{
+				nil,
+				{"LOG_LEVEL=info", "APPNAME=old"},
+				{"REVISION=metadata.labels['controller-revision-hash']", "NODE_NAME=spec.nodeName"},
+			},
+		},
+		{
+			name:      "deployment revision",
+			category:  CategoryDeployment,
+			injectEnv: &UnitInjectEnvSpec{Fields: []InjectEnvField{InjectEnvRevision}, Containers: []string{"sidecar"}},
+			want: [][]string{
+				nil,
+				{"LOG_LEVEL=info", "APPNAME=old"},
+				{"REVISION=metadata.labels['pod-template-hash']"},
+			},
+		},
+	}
+	for _, c := range cases {
+		instance := &Unit{ObjectMeta: metav1.ObjectMeta{Name: "unit", Namespace: "default"}}
+		instance.Spec.Category = c.category
+		instance.Spec.InjectEnv = c.injectEnv
+		template := newTemplate()
+		InjectEnv(instance, template)
+
+		containers := append(template.Spec.InitContainers, template.Spec.Containers...)
+		for i, container := range containers {
+			if got := envSummary(container); !reflect.DeepEqual(got, c.want[i]) {
+				t.Errorf("%s: env of container %s = %v, want %v", c.name, container.Name, got, c.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// own resource 调谐结果对应的Unit Event reason
+const (
+	EventReasonCreated string = "Created"
+	EventReasonUpdated string = "Updated"
+	EventReasonDeleted string = "Deleted"
+	EventReasonFailed  string = "Failed"
+	// own resource在Unit之外被删除后重新创建
+	EventReasonRecreated string = "Recreated"
+	// 已有Service的clusterIP与期望不一致，且不允许重建
+	EventReasonClusterIPMismatch string = "ClusterIPMismatch"
+)
+
+// 创建own resource，并将结果以Event的形式记录到Unit上，kubectl describe unit 即可看到
+func createOwnResource(instance *Unit, c client.Client, recorder record.EventRecorder,
+	kind string, obj runtime.Object) error {
+
+	err := c.Create(context.TODO(), obj)
+	recordOwnResourceEvent(instance, recorder, "create", EventReasonCreated, kind, obj, err)
+	return err
+}
+
+// 记录own resource操作结果的Event，失败时带上API error的reason
+func recordOwnResourceEvent(instance *Unit, recorder record.EventRecorder, action, reason, kind string,
+	obj runtime.Object, err error) {
+
+	name := instance.Name
+	if accessor, accessorErr := meta.Accessor(obj); accessorErr == nil {
+		name = accessor.GetName()
+	}
+
+	if err != nil {
+		apiReason := string(errors.ReasonForError(err))
+		if apiReason == "" {
+			apiReason = "Unknown"
+		}
+		RecordEvent(instance, recorder, corev1.EventTypeWarning, EventReasonFailed, "Failed to %s %s %s/%s, reason: %s, error: %v",
+			action, kind, instance.Namespace, name, apiReason, err)
+		return
+	}
+	RecordEvent(instance, recorder, corev1.EventTypeNormal, reason, "%s %s %s/%s", reason, kind, instance.Namespace, name)
+}
+
+// 在Unit上记录Event，所有Event都经过这里，recorder为空时(例如测试中直接调用)不记录
+func RecordEvent(instance *Unit, recorder record.EventRecorder, eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+	recorder.Eventf(instance, eventType, reason, messageFmt, args...)
+}
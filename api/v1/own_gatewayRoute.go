@@ -0,0 +1,311 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Gateway API 的HTTPRoute，controller不依赖gateway-api的Go类型，统一使用unstructured对象
+const (
+	GatewayAPIVersion string = "gateway.networking.k8s.io/v1"
+	HTTPRouteKind     string = "HTTPRoute"
+)
+
+// HTTPRoute 挂载的Gateway
+type GatewayParentRef struct {
+	// 默认为 gateway.networking.k8s.io
+	Group *string `json:"group,omitempty"`
+	// 默认为 Gateway
+	Kind *string `json:"kind,omitempty"`
+	// 默认为Unit所在的namespace
+	Namespace *string `json:"namespace,omitempty"`
+	Name      string  `json:"name"`
+	// Gateway的listener名称
+	SectionName *string `json:"sectionName,omitempty"`
+	Port        *int32  `json:"port,omitempty"`
+}
+
+type GatewayPathMatch struct {
+	// PathPrefix / Exact / RegularExpression，默认PathPrefix
+	// +kubebuilder:validation:Enum=PathPrefix;Exact;RegularExpression
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value"`
+}
+
+type GatewayHeaderMatch struct {
+	// Exact / RegularExpression，默认Exact
+	// +kubebuilder:validation:Enum=Exact;RegularExpression
+	Type  string `json:"type,omitempty"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// 一组匹配条件，path和headers需要同时满足
+type GatewayRouteMatch struct {
+	Path    *GatewayPathMatch    `json:"path,omitempty"`
+	Headers []GatewayHeaderMatch `json:"headers,omitempty"`
+}
+
+// 后端Service，按weight分配流量
+type GatewayBackendRef struct {
+	// 默认为Unit自身的Service
+	Name string `json:"name,omitempty"`
+	// 默认为Unit自身Service的第一个端口，在admission validating webhook里会校验是否为OwnService中声明的端口
+	Port   *int32 `json:"port,omitempty"`
+	Weight *int32 `json:"weight,omitempty"`
+}
+
+type GatewayRouteRule struct {
+	// 满足任意一组匹配条件即转发到backendRefs，未指定时匹配所有请求
+	Matches []GatewayRouteMatch `json:"matches,omitempty"`
+	// 未指定时转发到Unit自身的Service
+	BackendRefs []GatewayBackendRef `json:"backendRefs,omitempty"`
+}
+
+// gateway api HTTPRoute信息
+type OwnGatewayRoute struct {
+	ParentRefs []GatewayParentRef `json:"parentRefs"`
+	Hostnames  []string           `json:"hostnames,omitempty"`
+	// 未指定时只有一条转发到Unit自身Service的规则
+	Rules []GatewayRouteRule `json:"rules,omitempty"`
+}
+
+// HTTPRoute在每个Gateway上的状态，conditions包括Accepted和ResolvedRefs
+type UnitGatewayRouteParentStatus struct {
+	// namespace/name 形式的Gateway
+	Gateway        string          `json:"gateway"`
+	ControllerName string          `json:"controllerName,omitempty"`
+	Conditions     []UnitCondition `json:"conditions,omitempty"`
+}
+
+func newHTTPRouteObject() *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetAPIVersion(GatewayAPIVersion)
+	route.SetKind(HTTPRouteKind)
+	return route
+}
+
+// 未指定后端时默认使用Unit自身的Service及其第一个端口
+func (ownGatewayRoute *OwnGatewayRoute) makeBackendRef(instance *Unit, backend GatewayBackendRef) map[string]interface{} {
+	backendRef := map[string]interface{}{"name": backend.Name}
+	if backend.Name == "" {
+		backendRef["name"] = instance.Name
+	}
+	if backend.Port != nil {
+		backendRef["port"] = int64(*backend.Port)
+	} else if service := instance.Spec.RelationResource.Service; service != nil && len(service.Ports) > 0 {
+		backendRef["port"] = int64(service.Ports[0].Port)
+	}
+	if backend.Weight != nil {
+		backendRef["weight"] = int64(*backend.Weight)
+	}
+	return backendRef
+}
+
+func makeRouteMatch(match GatewayRouteMatch) map[string]interface{} {
+	routeMatch := map[string]interface{}{}
+	if match.Path != nil {
+		pathType := match.Path.Type
+		if pathType == "" {
+			pathType = "PathPrefix"
+		}
+		routeMatch["path"] = map[string]interface{}{"type": pathType, "value": match.Path.Value}
+	}
+	var headers []interface{}
+	for _, header := range match.Headers {
+		headerType := header.Type
+		if headerType == "" {
+			headerType = "Exact"
+		}
+		headers = append(headers, map[string]interface{}{"type": headerType, "name": header.Name, "value": header.Value})
+	}
+	if len(headers) > 0 {
+		routeMatch["headers"] = headers
+	}
+	return routeMatch
+}
+
+func (ownGatewayRoute *OwnGatewayRoute) MakeOwnResource(instance *Unit, logger logr.Logger,
+	scheme *runtime.Scheme) (interface{}, error) {
+
+	// new a HTTPRoute object
+	route := newHTTPRouteObject()
+	// metadata field inherited from owner Unit
+	route.SetName(instance.Name)
+	route.SetNamespace(instance.Namespace)
+	route.SetLabels(instance.Labels)
+
+	var parentRefs []interface{}
+	for _, parent := range ownGatewayRoute.ParentRefs {
+		parentRef := map[string]interface{}{"name": parent.Name}
+		if parent.Group != nil {
+			parentRef["group"] = *parent.Group
+		}
+		if parent.Kind != nil {
+			parentRef["kind"] = *parent.Kind
+		}
+		if parent.Namespace != nil {
+			parentRef["namespace"] = *parent.Namespace
+		}
+		if parent.SectionName != nil {
+			parentRef["sectionName"] = *parent.SectionName
+		}
+		if parent.Port != nil {
+			parentRef["port"] = int64(*parent.Port)
+		}
+		parentRefs = append(parentRefs, parentRef)
+	}
+	spec := map[string]interface{}{"parentRefs": parentRefs}
+
+	if len(ownGatewayRoute.Hostnames) > 0 {
+		var hostnames []interface{}
+		for _, hostname := range ownGatewayRoute.Hostnames {
+			hostnames = append(hostnames, hostname)
+		}
+		spec["hostnames"] = hostnames
+	}
+
+	routeRules := ownGatewayRoute.Rules
+	if len(routeRules) == 0 {
+		routeRules = []GatewayRouteRule{{}}
+	}
+	var rules []interface{}
+	for _, routeRule := range routeRules {
+		rule := map[string]interface{}{}
+
+		var matches []interface{}
+		for _, match := range routeRule.Matches {
+			matches = append(matches, makeRouteMatch(match))
+		}
+		if len(matches) > 0 {
+			rule["matches"] = matches
+		}
+
+		backends := routeRule.BackendRefs
+		if len(backends) == 0 {
+			backends = []GatewayBackendRef{{}}
+		}
+		var backendRefs []interface{}
+		for _, backend := range backends {
+			backendRefs = append(backendRefs, ownGatewayRoute.makeBackendRef(instance, backend))
+		}
+		rule["backendRefs"] = backendRefs
+
+		rules = append(rules, rule)
+	}
+	spec["rules"] = rules
+	route.Object["spec"] = spec
+
+	// add ControllerReference for HTTPRoute，the owner is Unit object
+	if err := controllerutil.SetControllerReference(instance, route, scheme); err != nil {
+		msg := fmt.Sprintf("set controllerReference for HTTPRoute %s/%s failed", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return nil, err
+	}
+
+	return route, nil
+}
+
+// Check if the HTTPRoute already exists
+func (ownGatewayRoute *OwnGatewayRoute) OwnResourceExist(instance *Unit, client client.Client,
+	logger logr.Logger) (bool, interface{}, error) {
+
+	found := newHTTPRouteObject()
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		msg := fmt.Sprintf("HTTPRoute %s/%s found, but with error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return true, found, err
+	}
+	return true, found, nil
+}
+
+// 将HTTPRoute在各个Gateway上的Accepted/ResolvedRefs等conditions同步到Unit.status
+func (ownGatewayRoute *OwnGatewayRoute) UpdateOwnResourceStatus(instance *Unit, client client.Client,
+	logger logr.Logger) (*Unit, error) {
+
+	found := newHTTPRouteObject()
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		return instance, err
+	}
+
+	instance.Status.RelationResourceStatus.GatewayRoute = parseHTTPRouteStatus(found)
+	instance.Status.LastUpdateTime = metav1.Now()
+
+	return instance, nil
+}
+
+// apply this own resource, create or update
+func (ownGatewayRoute *OwnGatewayRoute) ApplyOwnResource(instance *Unit, client client.Client,
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
+
+	// assert if HTTPRoute exist
+	_, found, err := ownGatewayRoute.OwnResourceExist(instance, client, logger)
+	if err != nil {
+		return err
+	}
+
+	// make HTTPRoute object
+	route, err := ownGatewayRoute.MakeOwnResource(instance, logger, scheme)
+	if err != nil {
+		return err
+	}
+	newRoute := route.(*unstructured.Unstructured)
+
+	// apply the HTTPRoute object just make，通过server-side apply 创建或更新，只管理Unit指定的字段
+	return applyOwnResource(instance, client, logger, scheme, recorder, HTTPRouteKind, newRoute, found)
+}
+
+// 解析HTTPRoute的status.parents
+func parseHTTPRouteStatus(route *unstructured.Unstructured) []UnitGatewayRouteParentStatus {
+	var parentsStatus []UnitGatewayRouteParentStatus
+	parents, _, _ := unstructured.NestedSlice(route.Object, "status", "parents")
+	for _, item := range parents {
+		parent, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(parent, "parentRef", "name")
+		namespace, found, _ := unstructured.NestedString(parent, "parentRef", "namespace")
+		if !found {
+			namespace = route.GetNamespace()
+		}
+		parentStatus := UnitGatewayRouteParentStatus{Gateway: namespace + "/" + name}
+		parentStatus.ControllerName, _, _ = unstructured.NestedString(parent, "controllerName")
+
+		conditions, _, _ := unstructured.NestedSlice(parent, "conditions")
+		for _, conditionItem := range conditions {
+			condition, ok := conditionItem.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			unitCondition := UnitCondition{}
+			unitCondition.Type, _, _ = unstructured.NestedString(condition, "type")
+			status, _, _ := unstructured.NestedString(condition, "status")
+			unitCondition.Status = corev1.ConditionStatus(status)
+			unitCondition.ObservedGeneration, _, _ = unstructured.NestedInt64(condition, "observedGeneration")
+			unitCondition.Reason, _, _ = unstructured.NestedString(condition, "reason")
+			unitCondition.Message, _, _ = unstructured.NestedString(condition, "message")
+			if transitionTime, _, _ := unstructured.NestedString(condition, "lastTransitionTime"); transitionTime != "" {
+				_ = unitCondition.LastTransitionTime.UnmarshalQueryParameter(transitionTime)
+			}
+			parentStatus.Conditions = append(parentStatus.Conditions, unitCondition)
+		}
+		parentsStatus = append(parentsStatus, parentStatus)
+	}
+	return parentsStatus
+}
@@ -0,0 +1,149 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// HPA信息，scaleTargetRef指向Unit自身，通过Unit的scale subresource调整spec.replicas
+type OwnHPA struct {
+	Spec autoscalingv2beta2.HorizontalPodAutoscalerSpec `json:"spec"`
+}
+
+// 根据Unit.Spec.Autoscaling生成HPA的spec
+func NewOwnHPA(instance *Unit) *OwnHPA {
+	autoscaling := instance.Spec.Autoscaling
+	ownHPA := &OwnHPA{
+		Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+				APIVersion: GroupVersion.String(),
+				Kind:       "Unit",
+				Name:       instance.Name,
+			},
+			MinReplicas: autoscaling.MinReplicas,
+			MaxReplicas: autoscaling.MaxReplicas,
+		},
+	}
+
+	resourceMetrics := []struct {
+		name   v1.ResourceName
+		target *int32
+	}{
+		{v1.ResourceCPU, autoscaling.TargetCPUUtilizationPercentage},
+		{v1.ResourceMemory, autoscaling.TargetMemoryUtilizationPercentage},
+	}
+	for _, metric := range resourceMetrics {
+		if metric.target == nil {
+			continue
+		}
+		ownHPA.Spec.Metrics = append(ownHPA.Spec.Metrics, autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.ResourceMetricSourceType,
+			Resource: &autoscalingv2beta2.ResourceMetricSource{
+				Name: metric.name,
+				Target: autoscalingv2beta2.MetricTarget{
+					Type:               autoscalingv2beta2.UtilizationMetricType,
+					AverageUtilization: metric.target,
+				},
+			},
+		})
+	}
+	ownHPA.Spec.Metrics = append(ownHPA.Spec.Metrics, autoscaling.Metrics...)
+	return ownHPA
+}
+
+func (ownHPA *OwnHPA) MakeOwnResource(instance *Unit, logger logr.Logger,
+	scheme *runtime.Scheme) (interface{}, error) {
+
+	// new a HPA object
+	hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{
+		// metadata field inherited from owner Unit
+		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace, Labels: instance.Labels},
+		Spec:       ownHPA.Spec,
+	}
+
+	// add ControllerReference for hpa，the owner is Unit object
+	if err := controllerutil.SetControllerReference(instance, hpa, scheme); err != nil {
+		msg := fmt.Sprintf("set controllerReference for HPA %s/%s failed", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return nil, err
+	}
+
+	return hpa, nil
+}
+
+// Check if the HPA already exists
+func (ownHPA *OwnHPA) OwnResourceExist(instance *Unit, client client.Client,
+	logger logr.Logger) (bool, interface{}, error) {
+
+	found := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		msg := fmt.Sprintf("HPA %s/%s found, but with error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return true, found, err
+	}
+	return true, found, nil
+}
+
+func (ownHPA *OwnHPA) UpdateOwnResourceStatus(instance *Unit, client client.Client,
+	logger logr.Logger) (*Unit, error) {
+
+	found := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		return instance, err
+	}
+
+	instance.Status.Autoscaling = found.Status.DeepCopy()
+	instance.Status.LastUpdateTime = metav1.Now()
+
+	return instance, nil
+}
+
+// apply this own resource, create or update
+func (ownHPA *OwnHPA) ApplyOwnResource(instance *Unit, client client.Client,
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
+
+	// assert if HPA exist
+	_, found, err := ownHPA.OwnResourceExist(instance, client, logger)
+	if err != nil {
+		return err
+	}
+
+	// make HPA object
+	hpa, err := ownHPA.MakeOwnResource(instance, logger, scheme)
+	if err != nil {
+		return err
+	}
+	newHPA := hpa.(*autoscalingv2beta2.HorizontalPodAutoscaler)
+
+	// apply the HPA object just make，通过server-side apply 创建或更新，只管理Unit指定的字段
+	return applyOwnResource(instance, client, logger, scheme, recorder, "HorizontalPodAutoscaler", newHPA, found)
+}
+
+// 填充scale subresource所需的status.replicas和status.selector，HPA通过它们获取当前副本数和pod
+func setScaleStatus(instance *Unit, replicas int32, selector *metav1.LabelSelector) error {
+	instance.Status.Replicas = &replicas
+	if selector == nil {
+		return nil
+	}
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return err
+	}
+	instance.Status.Selector = labelSelector.String()
+	return nil
+}
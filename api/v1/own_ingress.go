@@ -4,59 +4,269 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-logr/logr"
-	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"reflect"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// 集群可能提供的Ingress API版本，新集群只提供networking.k8s.io/v1，老集群只提供extensions/v1beta1
+const (
+	IngressAPIVersionNetworkingV1      string = "networking.k8s.io/v1"
+	IngressAPIVersionNetworkingV1beta1 string = "networking.k8s.io/v1beta1"
+	IngressAPIVersionExtensionsV1beta1 string = "extensions/v1beta1"
+)
+
+// ingress的一条转发路径
+type IngressPath struct {
+	// 默认为 "/"
+	Path string `json:"path,omitempty"`
+	// Prefix / Exact / ImplementationSpecific，默认Prefix，仅networking.k8s.io/v1生效
+	// +kubebuilder:validation:Enum=Prefix;Exact;ImplementationSpecific
+	PathType string `json:"pathType,omitempty"`
+	// 后端Service，对应spec.relationResource.services中Service的name，转发到 <Unit名称>-<serviceName>；
+	// 未指定时转发到serviceInfo中的主Service
+	ServiceName string `json:"serviceName,omitempty"`
+	// 后端Service端口，端口号或端口名称均可。
+	// 未指定时，主Service使用OwnIngress.ServicePort，services中的Service使用它的第一个端口
+	ServicePort *intstr.IntOrString `json:"servicePort,omitempty"`
+}
+
+// 一个域名及其转发路径
+type IngressHost struct {
+	Host string `json:"host"`
+	// 未指定时只有一条 "/" 路径
+	Paths []IngressPath `json:"paths,omitempty"`
+}
+
+// https证书配置，secret需要和Unit在同一个namespace下
+type IngressTLS struct {
+	// 未指定时使用全部域名
+	Hosts      []string `json:"hosts,omitempty"`
+	SecretName string   `json:"secretName"`
+}
+
 // ingress信息
 type OwnIngress struct {
-	Domains []string `json:"domain"`
+	// 兼容旧的配置，这里的每个域名都只有一条 "/" 路径
+	Domains []string `json:"domain,omitempty"`
+	// 需要按路径转发时使用hosts
+	Hosts []IngressHost `json:"hosts,omitempty"`
+	// 主Service的端口，端口号或端口名称均可，在admission validating webhook里会校验是否为OwnService中声明的端口。
+	// 未指定时使用OwnService的第一个端口
+	ServicePort *intstr.IntOrString `json:"servicePort,omitempty"`
+	TLS         []IngressTLS        `json:"tls,omitempty"`
+	// networking.k8s.io/v1 使用spec.ingressClassName，v1beta1 使用 kubernetes.io/ingress.class 注解
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+	// 原样透传到Ingress的annotations，例如 nginx.ingress.kubernetes.io/rewrite-target
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// 集群提供的Ingress API版本，由controller启动时通过discovery探测得到，不由前端指定
+	APIVersion string `json:"-"`
+}
+
+// Ingress的转发规则，与Ingress API版本无关
+type UnitIngressRuleStatus struct {
+	Host  string                  `json:"host,omitempty"`
+	Paths []UnitIngressPathStatus `json:"paths,omitempty"`
+}
+
+type UnitIngressPathStatus struct {
+	Path        string             `json:"path,omitempty"`
+	PathType    string             `json:"pathType,omitempty"`
+	ServiceName string             `json:"serviceName"`
+	ServicePort intstr.IntOrString `json:"servicePort"`
+}
+
+// ingress.class 注解，v1beta1 Ingress还没有spec.ingressClassName字段
+const IngressClassAnnotation string = "kubernetes.io/ingress.class"
+
+// networking.k8s.io/v1 要求每条路径都指定pathType
+const defaultIngressPathType string = "Prefix"
+
+// 当前使用的Ingress GVK，未探测时沿用extensions/v1beta1
+func (ownIngress *OwnIngress) groupVersionKind() schema.GroupVersionKind {
+	apiVersion := ownIngress.APIVersion
+	if apiVersion == "" {
+		apiVersion = IngressAPIVersionExtensionsV1beta1
+	}
+	return schema.FromAPIVersionAndKind(apiVersion, "Ingress")
+}
+
+func (ownIngress *OwnIngress) newIngressObject() *unstructured.Unstructured {
+	ing := &unstructured.Unstructured{}
+	ing.SetGroupVersionKind(ownIngress.groupVersionKind())
+	return ing
+}
+
+// 汇总domain和hosts两种配置，得到所有域名及其转发路径
+func (ownIngress *OwnIngress) allHosts() []IngressHost {
+	var hosts []IngressHost
+	for _, domain := range ownIngress.Domains {
+		hosts = append(hosts, IngressHost{Host: domain})
+	}
+	hosts = append(hosts, ownIngress.Hosts...)
+	for index := range hosts {
+		if len(hosts[index].Paths) == 0 {
+			hosts[index].Paths = []IngressPath{{Path: "/"}}
+		}
+	}
+	return hosts
+}
+
+// 转发路径的后端Service，指定了serviceName时为services中同名的Service，否则为serviceInfo中的主Service。
+// 找不到时返回nil
+func backendService(instance *Unit, path IngressPath) *OwnService {
+	if path.ServiceName == "" {
+		return instance.Spec.RelationResource.Service
+	}
+	for i := range instance.Spec.RelationResource.Services {
+		if service := &instance.Spec.RelationResource.Services[i]; service.Name == path.ServiceName {
+			return service
+		}
+	}
+	return nil
+}
+
+// 转发路径对应的后端Service端口
+func (ownIngress *OwnIngress) backendPort(instance *Unit, path IngressPath) intstr.IntOrString {
+	if path.ServicePort != nil {
+		return *path.ServicePort
+	}
+	if ownIngress.ServicePort != nil && path.ServiceName == "" {
+		return *ownIngress.ServicePort
+	}
+	if service := backendService(instance, path); service != nil && len(service.Ports) > 0 {
+		return intstr.FromInt(int(service.Ports[0].Port))
+	}
+	return intstr.FromInt(80)
+}
+
+// 根据Unit的指定生成Ingress的转发规则
+func (ownIngress *OwnIngress) makeRules(instance *Unit) []UnitIngressRuleStatus {
+	var rules []UnitIngressRuleStatus
+	for _, host := range ownIngress.allHosts() {
+		rule := UnitIngressRuleStatus{Host: host.Host}
+		for _, path := range host.Paths {
+			pathStatus := UnitIngressPathStatus{
+				Path:        path.Path,
+				PathType:    path.PathType,
+				ServiceName: (&OwnService{Name: path.ServiceName}).ServiceName(instance),
+				ServicePort: ownIngress.backendPort(instance, path),
+			}
+			if pathStatus.Path == "" {
+				pathStatus.Path = "/"
+			}
+			if pathStatus.PathType == "" {
+				pathStatus.PathType = defaultIngressPathType
+			}
+			rule.Paths = append(rule.Paths, pathStatus)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// 按Ingress API版本生成path的backend，v1beta1为serviceName/servicePort，v1为service.name/service.port
+func makeIngressBackend(apiVersion string, path UnitIngressPathStatus) map[string]interface{} {
+	if apiVersion != IngressAPIVersionNetworkingV1 {
+		backend := map[string]interface{}{"serviceName": path.ServiceName}
+		if path.ServicePort.Type == intstr.String {
+			backend["servicePort"] = path.ServicePort.StrVal
+		} else {
+			backend["servicePort"] = int64(path.ServicePort.IntVal)
+		}
+		return backend
+	}
+
+	port := map[string]interface{}{}
+	if path.ServicePort.Type == intstr.String {
+		port["name"] = path.ServicePort.StrVal
+	} else {
+		port["number"] = int64(path.ServicePort.IntVal)
+	}
+	return map[string]interface{}{
+		"service": map[string]interface{}{"name": path.ServiceName, "port": port},
+	}
 }
 
 // make a new Ingress Object
+// Ingress的Go类型随API版本不同而不同，这里统一使用unstructured对象，按探测到的API版本生成
 func (ownIngress *OwnIngress) MakeOwnResource(instance *Unit, logger logr.Logger,
 	scheme *runtime.Scheme) (interface{}, error) {
 
 	// new a Ingress object
-	ing := &v1beta1.Ingress{
-		// metadata field inherited from owner Unit
-		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace, Labels: instance.Labels},
-	}
+	ing := ownIngress.newIngressObject()
+	// metadata field inherited from owner Unit
+	ing.SetName(instance.Name)
+	ing.SetNamespace(instance.Namespace)
+	ing.SetLabels(instance.Labels)
 
-	var rules []v1beta1.IngressRule
-	for _, domain := range ownIngress.Domains {
-		// 在Unit的使用场景里，ing只用作http流量，且无需复杂的路径判断，统一使用 "/" 路径
-		ingressPath := v1beta1.HTTPIngressPath{
-			Path: "/",
-			Backend: v1beta1.IngressBackend{
-				ServiceName: instance.Name,
-				ServicePort: intstr.IntOrString{IntVal: 80},
-			},
-		}
+	apiVersion := ing.GetAPIVersion()
+	spec := map[string]interface{}{}
 
-		rule := v1beta1.IngressRule{
-			Host: domain,
+	// annotations透传，v1beta1通过注解指定ingress class
+	annotations := make(map[string]string, len(ownIngress.Annotations)+1)
+	for key, value := range ownIngress.Annotations {
+		annotations[key] = value
+	}
+	if ownIngress.IngressClassName != nil {
+		if apiVersion == IngressAPIVersionNetworkingV1 {
+			spec["ingressClassName"] = *ownIngress.IngressClassName
+		} else {
+			annotations[IngressClassAnnotation] = *ownIngress.IngressClassName
+		}
+	}
+	if len(annotations) > 0 {
+		ing.SetAnnotations(annotations)
+	}
 
-			IngressRuleValue: v1beta1.IngressRuleValue{
-				HTTP: &v1beta1.HTTPIngressRuleValue{
-					Paths: []v1beta1.HTTPIngressPath{
-						ingressPath,
-					},
-				},
-			},
+	var rules []interface{}
+	var allDomains []interface{}
+	for _, rule := range ownIngress.makeRules(instance) {
+		var paths []interface{}
+		for _, path := range rule.Paths {
+			ingressPath := map[string]interface{}{
+				"path":    path.Path,
+				"backend": makeIngressBackend(apiVersion, path),
+			}
+			// v1beta1在老集群上没有pathType字段
+			if apiVersion == IngressAPIVersionNetworkingV1 {
+				ingressPath["pathType"] = path.PathType
+			}
+			paths = append(paths, ingressPath)
 		}
 
-		rules = append(rules, rule)
+		rules = append(rules, map[string]interface{}{
+			"host": rule.Host,
+			"http": map[string]interface{}{"paths": paths},
+		})
+		allDomains = append(allDomains, rule.Host)
 	}
+	spec["rules"] = rules
 
-	ing.Spec.Rules = rules
+	// https证书，未指定hosts时对全部域名生效
+	var tlsList []interface{}
+	for _, tls := range ownIngress.TLS {
+		hosts := allDomains
+		if len(tls.Hosts) > 0 {
+			hosts = nil
+			for _, host := range tls.Hosts {
+				hosts = append(hosts, host)
+			}
+		}
+		tlsList = append(tlsList, map[string]interface{}{"hosts": hosts, "secretName": tls.SecretName})
+	}
+	if len(tlsList) > 0 {
+		spec["tls"] = tlsList
+	}
+	ing.Object["spec"] = spec
 
 	// add ControllerReference for ingress，the owner is Unit object
 	if err := controllerutil.SetControllerReference(instance, ing, scheme); err != nil {
@@ -72,7 +282,7 @@ func (ownIngress *OwnIngress) MakeOwnResource(instance *Unit, logger logr.Logger
 func (ownIngress *OwnIngress) OwnResourceExist(instance *Unit, client client.Client,
 	logger logr.Logger) (bool, interface{}, error) {
 
-	found := &v1beta1.Ingress{}
+	found := ownIngress.newIngressObject()
 	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -89,13 +299,13 @@ func (ownIngress *OwnIngress) OwnResourceExist(instance *Unit, client client.Cli
 func (ownIngress *OwnIngress) UpdateOwnResourceStatus(instance *Unit, client client.Client,
 	logger logr.Logger) (*Unit, error) {
 
-	found := &v1beta1.Ingress{}
+	found := ownIngress.newIngressObject()
 	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
 	if err != nil {
 		return instance, err
 	}
 
-	instance.Status.RelationResourceStatus.Ingress = found.Spec.Rules
+	instance.Status.RelationResourceStatus.Ingress = parseIngressRules(found)
 	instance.Status.LastUpdateTime = metav1.Now()
 
 	return instance, nil
@@ -103,10 +313,10 @@ func (ownIngress *OwnIngress) UpdateOwnResourceStatus(instance *Unit, client cli
 
 // apply this own resource, create or update
 func (ownIngress *OwnIngress) ApplyOwnResource(instance *Unit, client client.Client,
-	logger logr.Logger, scheme *runtime.Scheme) error {
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
 
 	// assert if Ingress exist
-	exist, found, err := ownIngress.OwnResourceExist(instance, client, logger)
+	_, found, err := ownIngress.OwnResourceExist(instance, client, logger)
 	if err != nil {
 		return err
 	}
@@ -116,22 +326,64 @@ func (ownIngress *OwnIngress) ApplyOwnResource(instance *Unit, client client.Cli
 	if err != nil {
 		return err
 	}
-	newIngress := sts.(*v1beta1.Ingress)
+	newIngress := sts.(*unstructured.Unstructured)
 
-	// apply the Ingress object just make
-	if !exist {
-		// if Ingress not exist，then create it
-		msg := fmt.Sprintf("Ingress %s/%s not found, create it!", newIngress.Namespace, newIngress.Name)
-		logger.Info(msg)
-		return client.Create(context.TODO(), newIngress)
-	} else {
-		foundIngress := found.(*v1beta1.Ingress)
-		// if Ingress exist with change，then try to update it
-		if !reflect.DeepEqual(newIngress.Spec, foundIngress.Spec) {
-			msg := fmt.Sprintf("Updating Ingress %s/%s", newIngress.Namespace, newIngress.Name)
-			logger.Info(msg)
-			return client.Update(context.TODO(), newIngress)
+	// apply the Ingress object just make，通过server-side apply 创建或更新，只管理Unit指定的字段
+	return applyOwnResource(instance, client, logger, scheme, recorder, "Ingress", newIngress, found)
+}
+
+// 从不同版本的Ingress对象中解析出转发规则
+func parseIngressRules(ing *unstructured.Unstructured) []UnitIngressRuleStatus {
+	var rules []UnitIngressRuleStatus
+	ruleList, _, _ := unstructured.NestedSlice(ing.Object, "spec", "rules")
+	for _, item := range ruleList {
+		ruleMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rule := UnitIngressRuleStatus{}
+		rule.Host, _, _ = unstructured.NestedString(ruleMap, "host")
+
+		pathList, _, _ := unstructured.NestedSlice(ruleMap, "http", "paths")
+		for _, pathItem := range pathList {
+			pathMap, ok := pathItem.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			path := UnitIngressPathStatus{}
+			path.Path, _, _ = unstructured.NestedString(pathMap, "path")
+			path.PathType, _, _ = unstructured.NestedString(pathMap, "pathType")
+
+			if serviceName, found, _ := unstructured.NestedString(pathMap, "backend", "serviceName"); found {
+				// v1beta1
+				path.ServiceName = serviceName
+				path.ServicePort = parseIngressPort(pathMap, "backend", "servicePort")
+			} else {
+				// networking.k8s.io/v1
+				path.ServiceName, _, _ = unstructured.NestedString(pathMap, "backend", "service", "name")
+				if portName, found, _ := unstructured.NestedString(pathMap, "backend", "service", "port", "name"); found {
+					path.ServicePort = intstr.FromString(portName)
+				} else {
+					path.ServicePort = parseIngressPort(pathMap, "backend", "service", "port", "number")
+				}
+			}
+			rule.Paths = append(rule.Paths, path)
 		}
-		return nil
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// 端口在unstructured对象中可能是数字或字符串
+func parseIngressPort(obj map[string]interface{}, fields ...string) intstr.IntOrString {
+	value, _, _ := unstructured.NestedFieldNoCopy(obj, fields...)
+	switch port := value.(type) {
+	case string:
+		return intstr.FromString(port)
+	case int64:
+		return intstr.FromInt(int(port))
+	case float64:
+		return intstr.FromInt(int(port))
 	}
+	return intstr.IntOrString{}
 }
@@ -0,0 +1,131 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	JobPhaseRunning   string = "Running"
+	JobPhaseSucceeded string = "Succeeded"
+	JobPhaseFailed    string = "Failed"
+)
+
+type OwnJob struct {
+	Spec batchv1.JobSpec `json:"spec"`
+}
+
+func (ownJob *OwnJob) MakeOwnResource(instance *Unit, logger logr.Logger,
+	scheme *runtime.Scheme) (interface{}, error) {
+
+	// new a Job object
+	job := &batchv1.Job{
+		// metadata field inherited from owner Unit
+		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace, Labels: instance.Labels},
+		Spec:       ownJob.Spec,
+	}
+
+	// add ControllerReference for job，the owner is Unit object
+	if err := controllerutil.SetControllerReference(instance, job, scheme); err != nil {
+		msg := fmt.Sprintf("set controllerReference for Job %s/%s failed", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Check if the Job already exists
+func (ownJob *OwnJob) OwnResourceExist(instance *Unit, client client.Client,
+	logger logr.Logger) (bool, interface{}, error) {
+
+	found := &batchv1.Job{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		msg := fmt.Sprintf("Job %s/%s found, but with error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return true, found, err
+	}
+	return true, found, nil
+}
+
+func (ownJob *OwnJob) UpdateOwnResourceStatus(instance *Unit, client client.Client,
+	logger logr.Logger) (*Unit, error) {
+
+	found := &batchv1.Job{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		return instance, err
+	}
+	instance.Status.BaseJob = found.Status
+	instance.Status.JobHistory = []UnitJobStatus{makeJobHistory(found)}
+	instance.Status.LastUpdateTime = metav1.Now()
+	return instance, nil
+}
+
+// apply this own resource, create or update
+func (ownJob *OwnJob) ApplyOwnResource(instance *Unit, client client.Client,
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
+
+	// assert if Job exist
+	exist, _, err := ownJob.OwnResourceExist(instance, client, logger)
+	if err != nil {
+		return err
+	}
+
+	// Job的pod template在创建之后不可修改，因此Job只创建不更新，相关字段的变更由webhook拒绝。需要重新执行时删除旧的Job即可
+	if exist {
+		return nil
+	}
+
+	// make Job object
+	job, err := ownJob.MakeOwnResource(instance, logger, scheme)
+	if err != nil {
+		return err
+	}
+	newJob := job.(*batchv1.Job)
+
+	// if Job not exist，then create it
+	msg := fmt.Sprintf("Job %s/%s not found, create it!", newJob.Namespace, newJob.Name)
+	logger.Info(msg)
+	return createOwnResource(instance, client, recorder, "Job", newJob)
+}
+
+// 根据Job的status生成一条Unit的Job执行记录
+func makeJobHistory(job *batchv1.Job) UnitJobStatus {
+	history := UnitJobStatus{
+		Name:           job.Name,
+		Phase:          JobPhaseRunning,
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+		Active:         job.Status.Active,
+		Succeeded:      job.Status.Succeeded,
+		Failed:         job.Status.Failed,
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			history.Phase = JobPhaseSucceeded
+		case batchv1.JobFailed:
+			history.Phase = JobPhaseFailed
+		}
+	}
+	return history
+}
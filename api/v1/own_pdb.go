@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// pdb信息，minAvailable和maxUnavailable只能指定其一，都不指定时在mutate webhook里默认填充maxUnavailable为1，
+// 多副本时节点驱逐每次最多只驱逐一个pod，单副本时也不会阻塞节点驱逐。
+// DaemonSet/Job/CronJob类型的Unit不支持PDB
+type OwnPDB struct {
+	MinAvailable   *intstr.IntOrString `json:"minAvailable,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+type UnitRelationPDBStatus struct {
+	// 当前健康的pod数 / 期望健康的最少pod数
+	CurrentHealthy int32 `json:"currentHealthy"`
+	DesiredHealthy int32 `json:"desiredHealthy"`
+	// 当前还允许驱逐的pod数
+	DisruptionsAllowed int32 `json:"disruptionsAllowed"`
+	ExpectedPods       int32 `json:"expectedPods"`
+}
+
+func (ownPDB *OwnPDB) MakeOwnResource(instance *Unit, logger logr.Logger,
+	scheme *runtime.Scheme) (interface{}, error) {
+
+	// new a PDB object
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		// metadata field inherited from owner Unit
+		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace, Labels: instance.Labels},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable:   ownPDB.MinAvailable,
+			MaxUnavailable: ownPDB.MaxUnavailable,
+			Selector:       instance.Spec.Selector,
+		},
+	}
+
+	// add ControllerReference for pdb，the owner is Unit object
+	if err := controllerutil.SetControllerReference(instance, pdb, scheme); err != nil {
+		msg := fmt.Sprintf("set controllerReference for PDB %s/%s failed", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return nil, err
+	}
+
+	return pdb, nil
+}
+
+// Check if the PDB already exists
+func (ownPDB *OwnPDB) OwnResourceExist(instance *Unit, client client.Client,
+	logger logr.Logger) (bool, interface{}, error) {
+
+	found := &policyv1beta1.PodDisruptionBudget{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		msg := fmt.Sprintf("PDB %s/%s found, but with error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return true, found, err
+	}
+	return true, found, nil
+}
+
+func (ownPDB *OwnPDB) UpdateOwnResourceStatus(instance *Unit, client client.Client,
+	logger logr.Logger) (*Unit, error) {
+
+	found := &policyv1beta1.PodDisruptionBudget{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	if err != nil {
+		return instance, err
+	}
+
+	instance.Status.RelationResourceStatus.PDB = UnitRelationPDBStatus{
+		CurrentHealthy:     found.Status.CurrentHealthy,
+		DesiredHealthy:     found.Status.DesiredHealthy,
+		DisruptionsAllowed: found.Status.PodDisruptionsAllowed,
+		ExpectedPods:       found.Status.ExpectedPods,
+	}
+	instance.Status.LastUpdateTime = metav1.Now()
+
+	return instance, nil
+}
+
+// apply this own resource, create or update
+func (ownPDB *OwnPDB) ApplyOwnResource(instance *Unit, client client.Client,
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
+
+	// assert if PDB exist
+	_, found, err := ownPDB.OwnResourceExist(instance, client, logger)
+	if err != nil {
+		return err
+	}
+
+	// make PDB object
+	pdb, err := ownPDB.MakeOwnResource(instance, logger, scheme)
+	if err != nil {
+		return err
+	}
+	newPDB := pdb.(*policyv1beta1.PodDisruptionBudget)
+
+	// apply the PDB object just make，通过server-side apply 创建或更新，只管理Unit指定的字段
+	return applyOwnResource(instance, client, logger, scheme, recorder, "PodDisruptionBudget", newPDB, found)
+}
@@ -5,18 +5,356 @@ import (
 	"fmt"
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sort"
+	"strings"
 )
 
 // pvc声明信息
 type OwnPVC struct {
+	// volume名称，spec.relationResource.volumes中必须指定。
+	// Deployment等类型的Unit创建名为 <Unit名称>-<name> 的共享PVC，StatefulSet类型的Unit转换为同名的volumeClaimTemplate，
+	// 每个副本一个PVC。pvcInfo中的PVC不需要指定，名称与Unit相同，且不会自动挂载
+	Name string `json:"name,omitempty"`
+	// 创建后只允许扩容storage。StatefulSet的volumeClaimTemplates创建后不可修改，
+	// 扩容时逐个修改已有副本的PVC，之后新增副本的PVC先按原容量创建，绑定后再扩容到此容量
 	Spec v1.PersistentVolumeClaimSpec ` json:"spec"`
+
+	// 挂载到pod中的路径，volumes中必须指定
+	MountPath string `json:"mountPath,omitempty"`
+	SubPath   string `json:"subPath,omitempty"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+	// 挂载到哪些容器，默认挂载到所有容器(不包括initContainers)
+	Containers []string `json:"containers,omitempty"`
+
+	// Unit删除时PVC的处理方式，默认Delete。
+	// Delete: PVC随Unit一起被回收；Retain: 去掉PVC的ownerReference，保留PVC；Snapshot: 先创建VolumeSnapshot，就绪后再回收PVC。
+	// StatefulSet每个副本的PVC不属于Unit，Unit删除后始终保留，Snapshot时同样会为它们创建快照
+	// 只对默认的background删除有效：以foreground方式(propagationPolicy为Foreground)删除Unit时，
+	// gc可能在controller处理retainPolicy之前就删除了PVC，需要保留数据时不要使用foreground删除
+	// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+	RetainPolicy string `json:"retainPolicy,omitempty"`
+	// retainPolicy为Snapshot以及定时备份时使用的VolumeSnapshotClass，默认使用集群默认的VolumeSnapshotClass
+	SnapshotClassName *string `json:"snapshotClassName,omitempty"`
+
+	// 按cron周期为PVC创建VolumeSnapshot
+	Backup *PVCBackupSpec `json:"backup,omitempty"`
+	// 从同namespace下指定的VolumeSnapshot恢复数据，只在PVC创建时生效，
+	// StatefulSet的每个副本都会从此快照恢复
+	RestoreFrom string `json:"restoreFrom,omitempty"`
+}
+
+// 定时备份配置
+type PVCBackupSpec struct {
+	// cron格式的备份周期，例如 "0 2 * * *"
+	Schedule string `json:"schedule"`
+	// 每个PVC保留的备份数量，超出后删除最旧的备份，默认7
+	// +kubebuilder:validation:Minimum=1
+	Retain *int32 `json:"retain,omitempty"`
+	// 暂停备份，已有的备份不受影响
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// 定时备份默认保留的快照数量
+const DefaultBackupRetain int32 = 7
+
+// PVC回收策略
+const (
+	PVCRetainPolicyDelete   string = "Delete"
+	PVCRetainPolicyRetain   string = "Retain"
+	PVCRetainPolicySnapshot string = "Snapshot"
+)
+
+// 每个PVC的状态
+type UnitVolumeStatus struct {
+	// spec.relationResource.volumes中的volume名称
+	Name        string                          `json:"name"`
+	ClaimName   string                          `json:"claimName"`
+	Phase       v1.PersistentVolumeClaimPhase   `json:"phase,omitempty"`
+	Capacity    v1.ResourceList                 `json:"capacity,omitempty"`
+	AccessModes []v1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// 申请的存储容量，扩容期间大于capacity
+	RequestedStorage *resource.Quantity `json:"requestedStorage,omitempty"`
+	// 扩容进度，例如 Resizing / FileSystemResizePending
+	Conditions []v1.PersistentVolumeClaimCondition `json:"conditions,omitempty"`
+}
+
+// 指定了restoreFrom时，PVC以VolumeSnapshot作为dataSource
+func (ownPVC *OwnPVC) claimSpec() *v1.PersistentVolumeClaimSpec {
+	spec := ownPVC.Spec.DeepCopy()
+	if ownPVC.RestoreFrom != "" {
+		apiGroup := VolumeSnapshotGroup
+		spec.DataSource = &v1.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     VolumeSnapshotKind,
+			Name:     ownPVC.RestoreFrom,
+		}
+	}
+	return spec
+}
+
+// pvcInfo中的PVC与Unit同名，volumes中的共享PVC以name作为后缀
+func (ownPVC *OwnPVC) ClaimName(instance *Unit) string {
+	if ownPVC.Name == "" {
+		return instance.Name
+	}
+	return instance.Name + "-" + ownPVC.Name
+}
+
+// 返回此volume对应的所有PVC：pvcInfo和共享PVC只有一个，StatefulSet的volumeClaimTemplates每个副本一个。
+// StatefulSet controller按 <template名称>-<StatefulSet名称>-<序号> 为每个副本创建PVC，并带上StatefulSet的selector label
+func (ownPVC *OwnPVC) ListClaims(instance *Unit, c client.Client) ([]v1.PersistentVolumeClaim, error) {
+	if ownPVC.Name == "" || instance.Spec.Category != CategoryStatefulSet {
+		found := &v1.PersistentVolumeClaim{}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: ownPVC.ClaimName(instance), Namespace: instance.Namespace}, found)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return []v1.PersistentVolumeClaim{*found}, nil
+	}
+
+	if instance.Spec.Selector == nil {
+		return nil, nil
+	}
+	pvcList := &v1.PersistentVolumeClaimList{}
+	err := c.List(context.TODO(), pvcList, client.InNamespace(instance.Namespace),
+		client.MatchingLabels(instance.Spec.Selector.MatchLabels))
+	if err != nil {
+		return nil, err
+	}
+	prefix := ownPVC.Name + "-" + instance.Name + "-"
+	var claims []v1.PersistentVolumeClaim
+	for _, pvc := range pvcList.Items {
+		if strings.HasPrefix(pvc.Name, prefix) {
+			claims = append(claims, pvc)
+		}
+	}
+	// 按副本序号排序
+	sort.Slice(claims, func(i, j int) bool {
+		return len(claims[i].Name) < len(claims[j].Name) ||
+			(len(claims[i].Name) == len(claims[j].Name) && claims[i].Name < claims[j].Name)
+	})
+	return claims, nil
+}
+
+// 将spec.relationResource.volumes挂载到pod模板中。
+// StatefulSet的volume由volumeClaimTemplates提供，只需要添加volumeMounts；其它类型引用共享的PVC
+func InjectVolumes(instance *Unit, template *v1.PodTemplateSpec) {
+	for _, volume := range instance.Spec.RelationResource.Volumes {
+		if instance.Spec.Category != CategoryStatefulSet {
+			template.Spec.Volumes = append(template.Spec.Volumes, v1.Volume{
+				Name: volume.Name,
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+						ClaimName: volume.ClaimName(instance),
+						ReadOnly:  volume.ReadOnly,
+					},
+				},
+			})
+		}
+
+		for i := range template.Spec.Containers {
+			container := &template.Spec.Containers[i]
+			if len(volume.Containers) > 0 && !containsString(volume.Containers, container.Name) {
+				continue
+			}
+			container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+				Name:      volume.Name,
+				MountPath: volume.MountPath,
+				SubPath:   volume.SubPath,
+				ReadOnly:  volume.ReadOnly,
+			})
+		}
+	}
+}
+
+// StatefulSet类型的Unit，spec.relationResource.volumes转换为volumeClaimTemplates
+func VolumeClaimTemplates(instance *Unit) []v1.PersistentVolumeClaim {
+	var templates []v1.PersistentVolumeClaim
+	for _, volume := range instance.Spec.RelationResource.Volumes {
+		templates = append(templates, v1.PersistentVolumeClaim{
+			// volumeClaimTemplates创建后不可修改，不继承Unit的label，StatefulSet controller会给PVC加上selector label
+			ObjectMeta: metav1.ObjectMeta{Name: volume.Name},
+			Spec:       *volume.claimSpec(),
+		})
+	}
+	return templates
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// StatefulSet的volumeClaimTemplates为每个副本创建的PVC的状态
+func volumeClaimTemplatesStatus(instance *Unit, c client.Client) ([]UnitVolumeStatus, error) {
+	var volumesStatus []UnitVolumeStatus
+	for i := range instance.Spec.RelationResource.Volumes {
+		volume := &instance.Spec.RelationResource.Volumes[i]
+		claims, err := volume.ListClaims(instance, c)
+		if err != nil {
+			return nil, err
+		}
+		for j := range claims {
+			volumesStatus = append(volumesStatus, newVolumeStatus(volume.Name, &claims[j]))
+		}
+	}
+	return volumesStatus, nil
+}
+
+func newVolumeStatus(name string, pvc *v1.PersistentVolumeClaim) UnitVolumeStatus {
+	volumeStatus := UnitVolumeStatus{
+		Name:        name,
+		ClaimName:   pvc.Name,
+		Phase:       pvc.Status.Phase,
+		Capacity:    pvc.Status.Capacity,
+		AccessModes: pvc.Status.AccessModes,
+		Conditions:  pvc.Status.Conditions,
+	}
+	if requested, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]; ok {
+		volumeStatus.RequestedStorage = &requested
+	}
+	return volumeStatus
+}
+
+// PVC创建后，除了resources.requests.storage可以调大用于扩容，其它spec字段都不可修改(volumeName还会由binder填充)，
+// 因此已存在的PVC不再apply整个spec，只patch label和扩容的storage。labels为nil时不修改label
+func expandPVC(instance *Unit, c client.Client, logger logr.Logger, recorder record.EventRecorder,
+	found *v1.PersistentVolumeClaim, spec v1.PersistentVolumeClaimSpec, labels map[string]string) error {
+
+	patched := found.DeepCopy()
+	for k, v := range labels {
+		if patched.Labels == nil {
+			patched.Labels = make(map[string]string, len(labels))
+		}
+		patched.Labels[k] = v
+	}
+
+	desired, ok := spec.Resources.Requests[v1.ResourceStorage]
+	current := found.Spec.Resources.Requests[v1.ResourceStorage]
+	expanding := ok && desired.Cmp(current) > 0 && found.Status.Phase == v1.ClaimBound
+	if ok && desired.Cmp(current) > 0 && !expanding {
+		// 只有已绑定的PVC才能扩容。例如StatefulSet扩容新增的副本，PVC按创建时的volumeClaimTemplates申请旧的容量，
+		// 绑定之后工作负载状态变化触发的调谐中再扩容
+		msg := fmt.Sprintf("PVC %s/%s is %s, expand it to %s after it is bound", found.Namespace, found.Name,
+			found.Status.Phase, desired.String())
+		logger.Info(msg)
+	}
+	if expanding {
+		// 缩容在admission validating webhook里已拒绝，这里只处理扩容
+		allowed, err := storageClassAllowsExpansion(c, found.Spec.StorageClassName)
+		if err != nil {
+			msg := fmt.Sprintf("get StorageClass of PVC %s/%s error", found.Namespace, found.Name)
+			logger.Error(err, msg)
+			return err
+		}
+		if !allowed {
+			err := fmt.Errorf("StorageClass of PVC %s/%s does not allow volume expansion", found.Namespace, found.Name)
+			recordOwnResourceEvent(instance, recorder, "expand", EventReasonFailed, "PersistentVolumeClaim", found, err)
+			return err
+		}
+		if patched.Spec.Resources.Requests == nil {
+			patched.Spec.Resources.Requests = v1.ResourceList{}
+		}
+		patched.Spec.Resources.Requests[v1.ResourceStorage] = desired
+	}
+
+	if reflect.DeepEqual(found.Labels, patched.Labels) && reflect.DeepEqual(found.Spec, patched.Spec) {
+		return nil
+	}
+	if err := c.Patch(context.TODO(), patched, client.MergeFrom(found)); err != nil {
+		recordOwnResourceEvent(instance, recorder, "update", EventReasonFailed, "PersistentVolumeClaim", patched, err)
+		return err
+	}
+	if expanding {
+		msg := fmt.Sprintf("Expand PVC %s/%s from %s to %s", found.Namespace, found.Name, current.String(), desired.String())
+		logger.Info(msg)
+	}
+	recordOwnResourceEvent(instance, recorder, "update", EventReasonUpdated, "PersistentVolumeClaim", patched, nil)
+	return nil
+}
+
+// 标记集群默认StorageClass的annotation，beta版本的annotation仍被兼容
+const (
+	defaultStorageClassAnnotation     string = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation string = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// 判断PVC的StorageClass是否允许扩容。
+// storageClassName为nil时使用集群默认的StorageClass，为""时表示不使用StorageClass(静态绑定PV)，不能扩容
+func storageClassAllowsExpansion(c client.Client, storageClassName *string) (bool, error) {
+	if storageClassName == nil {
+		storageClass, err := defaultStorageClass(c)
+		if err != nil || storageClass == nil {
+			return false, err
+		}
+		return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+	}
+	if *storageClassName == "" {
+		return false, nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: *storageClassName}, storageClass); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
+
+// 返回带有is-default-class annotation的StorageClass，没有时返回nil。
+// 与DefaultStorageClass admission plugin一致，有多个时使用最新创建的
+func defaultStorageClass(c client.Client) (*storagev1.StorageClass, error) {
+	storageClassList := &storagev1.StorageClassList{}
+	if err := c.List(context.TODO(), storageClassList); err != nil {
+		return nil, err
+	}
+	var found *storagev1.StorageClass
+	for i := range storageClassList.Items {
+		storageClass := &storageClassList.Items[i]
+		if storageClass.Annotations[defaultStorageClassAnnotation] != "true" &&
+			storageClass.Annotations[betaDefaultStorageClassAnnotation] != "true" {
+			continue
+		}
+		if found == nil || found.CreationTimestamp.Before(&storageClass.CreationTimestamp) {
+			found = storageClass
+		}
+	}
+	return found, nil
+}
+
+// StatefulSet的volumeClaimTemplates不可修改，扩容时直接patch每个副本的PVC
+func expandVolumeClaimTemplates(instance *Unit, c client.Client, logger logr.Logger, recorder record.EventRecorder) error {
+	for i := range instance.Spec.RelationResource.Volumes {
+		volume := &instance.Spec.RelationResource.Volumes[i]
+		claims, err := volume.ListClaims(instance, c)
+		if err != nil {
+			return err
+		}
+		for j := range claims {
+			if err := expandPVC(instance, c, logger, recorder, &claims[j], volume.Spec, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func (ownPVC *OwnPVC) MakeOwnResource(instance *Unit, logger logr.Logger,
@@ -25,13 +363,13 @@ func (ownPVC *OwnPVC) MakeOwnResource(instance *Unit, logger logr.Logger,
 	// new a PVC object
 	pvc := &v1.PersistentVolumeClaim{
 		// metadata field inherited from owner Unit
-		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace, Labels: instance.Labels},
-		Spec:       ownPVC.Spec,
+		ObjectMeta: metav1.ObjectMeta{Name: ownPVC.ClaimName(instance), Namespace: instance.Namespace, Labels: instance.Labels},
+		Spec:       *ownPVC.claimSpec(),
 	}
 
 	// add ControllerReference for sts，the owner is Unit object
 	if err := controllerutil.SetControllerReference(instance, pvc, scheme); err != nil {
-		msg := fmt.Sprintf("set controllerReference for PVC %s/%s failed", instance.Namespace, instance.Name)
+		msg := fmt.Sprintf("set controllerReference for PVC %s/%s failed", instance.Namespace, pvc.Name)
 		logger.Error(err, msg)
 		return nil, err
 	}
@@ -44,13 +382,14 @@ func (ownPVC *OwnPVC) OwnResourceExist(instance *Unit, client client.Client,
 	logger logr.Logger) (bool, interface{}, error) {
 
 	found := &v1.PersistentVolumeClaim{}
-	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	name := ownPVC.ClaimName(instance)
+	err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: instance.Namespace}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return false, nil, nil
 		}
 
-		msg := fmt.Sprintf("PVC %s/%s found, but with error", instance.Namespace, instance.Name)
+		msg := fmt.Sprintf("PVC %s/%s found, but with error", instance.Namespace, name)
 		logger.Error(err, msg)
 		return true, found, err
 	}
@@ -61,11 +400,18 @@ func (ownPVC *OwnPVC) UpdateOwnResourceStatus(instance *Unit, client client.Clie
 	logger logr.Logger) (*Unit, error) {
 
 	found := &v1.PersistentVolumeClaim{}
-	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	err := client.Get(context.TODO(), types.NamespacedName{Name: ownPVC.ClaimName(instance), Namespace: instance.Namespace}, found)
 	if err != nil {
 		return instance, err
 	}
 
+	if ownPVC.Name != "" {
+		// volumes中的共享PVC
+		instance.Status.RelationResourceStatus.Volumes = append(instance.Status.RelationResourceStatus.Volumes,
+			newVolumeStatus(ownPVC.Name, found))
+		instance.Status.LastUpdateTime = metav1.Now()
+		return instance, nil
+	}
 	instance.Status.RelationResourceStatus.PVC = found.Status
 	instance.Status.LastUpdateTime = metav1.Now()
 
@@ -74,10 +420,10 @@ func (ownPVC *OwnPVC) UpdateOwnResourceStatus(instance *Unit, client client.Clie
 
 // apply this own resource, create or update
 func (ownPVC *OwnPVC) ApplyOwnResource(instance *Unit, client client.Client,
-	logger logr.Logger, scheme *runtime.Scheme) error {
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
 
 	// assert if PVC exist
-	exist, found, err := ownPVC.OwnResourceExist(instance, client, logger)
+	_, found, err := ownPVC.OwnResourceExist(instance, client, logger)
 	if err != nil {
 		return err
 	}
@@ -89,24 +435,11 @@ func (ownPVC *OwnPVC) ApplyOwnResource(instance *Unit, client client.Client,
 	}
 	newPVC := pvc.(*v1.PersistentVolumeClaim)
 
-	// apply the PVC object just make
-	if !exist {
-		// if PVC not exist，then create it
-		msg := fmt.Sprintf("PVC %s/%s not found, create it!", newPVC.Namespace, newPVC.Name)
-		logger.Info(msg)
-
-		if err := client.Create(context.TODO(), newPVC); err != nil {
-			return err
-		}
-		return nil
-	} else {
-		foundPVC := found.(*v1.PersistentVolumeClaim)
-		// if PVC exist with change，then try to update it
-		if !reflect.DeepEqual(newPVC.Spec, foundPVC.Spec) {
-			msg := fmt.Sprintf("Updating PVC %s/%s", newPVC.Namespace, newPVC.Name)
-			logger.Info(msg)
-			return client.Update(context.TODO(), newPVC)
-		}
-		return nil
+	// 已存在的PVC只处理扩容
+	if found != nil {
+		return expandPVC(instance, client, logger, recorder, found.(*v1.PersistentVolumeClaim), newPVC.Spec, newPVC.Labels)
 	}
+
+	// apply the PVC object just make，通过server-side apply 创建，只管理Unit指定的字段
+	return applyOwnResource(instance, client, logger, scheme, recorder, "PersistentVolumeClaim", newPVC, found)
 }
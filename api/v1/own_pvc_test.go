@@ -0,0 +1,289 @@
+package v1
+
+import (
+	"context"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"testing"
+	"time"
+)
+
+func newTestStorageClass(name string, allowExpansion bool, created time.Time, annotations map[string]string) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Annotations:       annotations,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Provisioner:          "example.com/provisioner",
+		AllowVolumeExpansion: &allowExpansion,
+	}
+}
+
+func TestStorageClassAllowsExpansion(t *testing.T) {
+	now := time.Now()
+	isDefault := map[string]string{defaultStorageClassAnnotation: "true"}
+	isBetaDefault := map[string]string{betaDefaultStorageClassAnnotation: "true"}
+	name := func(s string) *string { return &s }
+
+	cases := []struct {
+		name             string
+		classes          []runtime.Object
+		storageClassName *string
+		want             bool
+	}{
+		{
+			name:             "named class allows expansion",
+			classes:          []runtime.Object{newTestStorageClass("fast", true, now, nil)},
+			storageClassName: name("fast"),
+			want:             true,
+		},
+		{
+			name:             "named class does not allow expansion",
+			classes:          []runtime.Object{newTestStorageClass("slow", false, now, isDefault)},
+			storageClassName: name("slow"),
+			want:             false,
+		},
+		{
+			name:             "named class not found",
+			storageClassName: name("missing"),
+			want:             false,
+		},
+		{
+			name:             "empty name means no class",
+			classes:          []runtime.Object{newTestStorageClass("fast", true, now, isDefault)},
+			storageClassName: name(""),
+			want:             false,
+		},
+		{
+			name:    "nil uses default class",
+			classes: []runtime.Object{newTestStorageClass("fast", true, now, isDefault), newTestStorageClass("slow", false, now, nil)},
+			want:    true,
+		},
+		{
+			name:    "nil uses beta default class",
+			classes: []runtime.Object{newTestStorageClass("fast", true, now, isBetaDefault)},
+			want:    true,
+		},
+		{
+			name: "nil uses newest default class",
+			classes: []runtime.Object{
+				newTestStorageClass("old", true, now.Add(-time.Hour), isDefault),
+				newTestStorageClass("new", false, now, isDefault),
+			},
+			want: false,
+		},
+		{
+			name:    "nil without default class",
+			classes: []runtime.Object{newTestStorageClass("fast", true, now, nil)},
+			want:    false,
+		},
+	}
+	for _, c := range cases {
+		client := fake.NewFakeClientWithScheme(clientgoscheme.Scheme, c.classes...)
+		got, err := storageClassAllowsExpansion(client, c.storageClassName)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: storageClassAllowsExpansion = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func newVolumeTestUnit(category string) *Unit {
+	instance := &Unit{ObjectMeta: metav1.ObjectMeta{Name: "unit", Namespace: "default"}}
+	instance.Spec.Category = category
+	instance.Spec.RelationResource.Volumes = []OwnPVC{
+		{Name: "data", MountPath: "/data"},
+		{Name: "logs", MountPath: "/logs", ReadOnly: true, Containers: []string{"sidecar"}, RestoreFrom: "logs-backup"},
+	}
+	return instance
+}
+
+func TestInjectVolumes(t *testing.T) {
+	cases := []struct {
+		name        string
+		category    string
+		wantVolumes []v1.Volume
+	}{
+		{
+			name:     "shared PVC",
+			category: CategoryDeployment,
+			wantVolumes: []v1.Volume{
+				{Name: "data", VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "unit-data"}}},
+				{Name: "logs", VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "unit-logs", ReadOnly: true}}},
+			},
+		},
+		{
+			// volume由volumeClaimTemplates提供
+			name:     "volumeClaimTemplates",
+			category: CategoryStatefulSet,
+		},
+	}
+	for _, c := range cases {
+		instance := newVolumeTestUnit(c.category)
+		template := &v1.PodTemplateSpec{Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "init"}},
+			Containers:     []v1.Container{{Name: "app"}, {Name: "sidecar"}},
+		}}
+		InjectVolumes(instance, template)
+
+		if !reflect.DeepEqual(template.Spec.Volumes, c.wantVolumes) {
+			t.Errorf("%s: volumes = %v, want %v", c.name, template.Spec.Volumes, c.wantVolumes)
+		}
+		wantMounts := map[string][]v1.VolumeMount{
+			"init":    nil,
+			"app":     {{Name: "data", MountPath: "/data"}},
+			"sidecar": {{Name: "data", MountPath: "/data"}, {Name: "logs", MountPath: "/logs", ReadOnly: true}},
+		}
+		for _, container := range append(template.Spec.InitContainers, template.Spec.Containers...) {
+			if !reflect.DeepEqual(container.VolumeMounts, wantMounts[container.Name]) {
+				t.Errorf("%s: volumeMounts of container %s = %v, want %v", c.name, container.Name, container.VolumeMounts, wantMounts[container.Name])
+			}
+		}
+	}
+}
+
+func TestVolumeClaimTemplates(t *testing.T) {
+	templates := VolumeClaimTemplates(newVolumeTestUnit(CategoryStatefulSet))
+	if len(templates) != 2 || templates[0].Name != "data" || templates[1].Name != "logs" {
+		t.Fatalf("unexpected volumeClaimTemplates %v", templates)
+	}
+	if templates[0].Spec.DataSource != nil {
+		t.Errorf("expected no dataSource without restoreFrom, got %v", templates[0].Spec.DataSource)
+	}
+	dataSource := templates[1].Spec.DataSource
+	if dataSource == nil || dataSource.Kind != VolumeSnapshotKind || dataSource.Name != "logs-backup" ||
+		dataSource.APIGroup == nil || *dataSource.APIGroup != VolumeSnapshotGroup {
+		t.Errorf("expected dataSource to reference VolumeSnapshot logs-backup, got %v", dataSource)
+	}
+}
+
+func TestExpandPVC(t *testing.T) {
+	now := time.Now()
+	fast, slow := "fast", "slow"
+	newClaim := func(storageClassName *string, storage string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "unit-data", Namespace: "default"},
+			Spec: v1.PersistentVolumeClaimSpec{
+				StorageClassName: storageClassName,
+				Resources:        v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(storage)}},
+			},
+			Status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound},
+		}
+	}
+	pending := newClaim(&fast, "1Gi")
+	pending.Status.Phase = v1.ClaimPending
+	newSpec := func(storage string) v1.PersistentVolumeClaimSpec {
+		return v1.PersistentVolumeClaimSpec{
+			Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(storage)}},
+		}
+	}
+
+	cases := []struct {
+		name        string
+		claim       *v1.PersistentVolumeClaim
+		spec        v1.PersistentVolumeClaimSpec
+		labels      map[string]string
+		wantErr     bool
+		wantStorage string
+		wantLabels  map[string]string
+	}{
+		{name: "expand", claim: newClaim(&fast, "1Gi"), spec: newSpec("2Gi"), wantStorage: "2Gi"},
+		{name: "expand with default class", claim: newClaim(nil, "1Gi"), spec: newSpec("2Gi"), wantStorage: "2Gi"},
+		{name: "class does not allow expansion", claim: newClaim(&slow, "1Gi"), spec: newSpec("2Gi"), wantErr: true, wantStorage: "1Gi"},
+		{name: "pending claim", claim: pending, spec: newSpec("2Gi"), wantStorage: "1Gi"},
+		{name: "same size", claim: newClaim(&slow, "1Gi"), spec: newSpec("1Gi"), wantStorage: "1Gi"},
+		{name: "labels only", claim: newClaim(&slow, "1Gi"), spec: newSpec("1Gi"), labels: map[string]string{"app": "unit"},
+			wantStorage: "1Gi", wantLabels: map[string]string{"app": "unit"}},
+	}
+	for _, c := range cases {
+		client := fake.NewFakeClientWithScheme(clientgoscheme.Scheme, c.claim,
+			newTestStorageClass("fast", true, now, map[string]string{defaultStorageClassAnnotation: "true"}),
+			newTestStorageClass("slow", false, now, nil))
+		instance := &Unit{ObjectMeta: metav1.ObjectMeta{Name: "unit", Namespace: "default"}}
+
+		err := expandPVC(instance, client, logf.Log.WithName("test"), nil, c.claim.DeepCopy(), c.spec, c.labels)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: expandPVC error = %v, wantErr %v", c.name, err, c.wantErr)
+		}
+
+		found := &v1.PersistentVolumeClaim{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: "unit-data", Namespace: "default"}, found); err != nil {
+			t.Fatalf("%s: get PVC error: %v", c.name, err)
+		}
+		storage := found.Spec.Resources.Requests[v1.ResourceStorage]
+		if want := resource.MustParse(c.wantStorage); storage.Cmp(want) != 0 {
+			t.Errorf("%s: storage = %s, want %s", c.name, storage.String(), c.wantStorage)
+		}
+		if !reflect.DeepEqual(found.Labels, c.wantLabels) {
+			t.Errorf("%s: labels = %v, want %v", c.name, found.Labels, c.wantLabels)
+		}
+	}
+}
+
+func TestExpandVolumeClaimTemplates(t *testing.T) {
+	instance := &Unit{ObjectMeta: metav1.ObjectMeta{Name: "unit", Namespace: "default"}}
+	instance.Spec.Category = CategoryStatefulSet
+	instance.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "unit"}}
+	instance.Spec.RelationResource.Volumes = []OwnPVC{{Name: "data", MountPath: "/data", Spec: v1.PersistentVolumeClaimSpec{
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("2Gi")}},
+	}}}
+	newClaim := func(name, storage string, phase v1.PersistentVolumeClaimPhase) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "unit"}},
+			Spec: v1.PersistentVolumeClaimSpec{
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(storage)}},
+			},
+			Status: v1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+	// data-unit-0和data-unit-1已经扩容，扩容之后新增的副本data-unit-2按volumeClaimTemplates中的原容量创建
+	client := fake.NewFakeClientWithScheme(clientgoscheme.Scheme,
+		newClaim("data-unit-0", "2Gi", v1.ClaimBound), newClaim("data-unit-1", "2Gi", v1.ClaimBound),
+		newClaim("data-unit-2", "1Gi", v1.ClaimPending),
+		newTestStorageClass("fast", true, time.Now(), map[string]string{defaultStorageClassAnnotation: "true"}))
+	key := types.NamespacedName{Name: "data-unit-2", Namespace: "default"}
+	storageOf := func() resource.Quantity {
+		found := &v1.PersistentVolumeClaim{}
+		if err := client.Get(context.TODO(), key, found); err != nil {
+			t.Fatalf("get PVC error: %v", err)
+		}
+		return found.Spec.Resources.Requests[v1.ResourceStorage]
+	}
+	expand := func() {
+		if err := expandVolumeClaimTemplates(instance, client, logf.Log.WithName("test"), nil); err != nil {
+			t.Fatalf("expandVolumeClaimTemplates error: %v", err)
+		}
+	}
+
+	// 还未绑定时不扩容
+	expand()
+	if storage := storageOf(); storage.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("expected pending PVC to keep 1Gi, got %s", storage.String())
+	}
+
+	// 绑定之后的调谐中扩容到spec中的容量
+	bound := &v1.PersistentVolumeClaim{}
+	if err := client.Get(context.TODO(), key, bound); err != nil {
+		t.Fatal(err)
+	}
+	bound.Status.Phase = v1.ClaimBound
+	if err := client.Status().Update(context.TODO(), bound); err != nil {
+		t.Fatal(err)
+	}
+	expand()
+	if storage := storageOf(); storage.Cmp(resource.MustParse("2Gi")) != 0 {
+		t.Errorf("expected PVC of the new replica to be expanded to 2Gi, got %s", storage.String())
+	}
+}
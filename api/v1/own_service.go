@@ -10,11 +10,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"net"
-	"reflect"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
-	"time"
 )
 
 // svc的端口映射关系
@@ -26,28 +24,145 @@ type ServicePort struct {
 	NodePort   int32              `json:"nodePort,omitempty" protobuf:"varint,5,opt,name=nodePort"`
 }
 
+// 端口健康检查的类型
+const (
+	HealthCheckTCP  string = "TCP"
+	HealthCheckHTTP string = "HTTP"
+	HealthCheckGRPC string = "GRPC"
+)
+
+type HTTPHealthCheck struct {
+	// 默认为 /
+	Path string `json:"path,omitempty"`
+	// +kubebuilder:validation:Enum=HTTP;HTTPS
+	Scheme string `json:"scheme,omitempty"`
+	// 视为健康的返回码，未指定时200~399视为健康
+	ExpectedStatus []int32 `json:"expectedStatus,omitempty"`
+}
+
+type GRPCHealthCheck struct {
+	// grpc.health.v1.HealthCheckRequest中的service，为空时检查整个server
+	Service string `json:"service,omitempty"`
+}
+
+// 端口的主动健康检查配置，需要按端口显式开启，由controller在后台按periodSeconds周期执行，结果写入status.relationResourceStatus的ports中。
+// controller需要能访问到Service的clusterIP(headless service为就绪pod的IP)，通常要求controller运行在集群内
+type ServicePortHealthCheck struct {
+	// 端口号或端口名称，必须是Service中声明的TCP端口
+	Port intstr.IntOrString `json:"port"`
+	// TCP / HTTP / GRPC，默认TCP
+	// +kubebuilder:validation:Enum=TCP;HTTP;GRPC
+	Type    string           `json:"type,omitempty"`
+	HTTPGet *HTTPHealthCheck `json:"httpGet,omitempty"`
+	GRPC    *GRPCHealthCheck `json:"grpc,omitempty"`
+	// 检查周期，默认10秒
+	// +kubebuilder:validation:Minimum=1
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+	// 单次检查超时时间，默认1秒
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
 type OwnService struct {
+	// Service名称后缀，Service名称为 <Unit名称>-<name>；
+	// serviceInfo中的主Service不需要指定，名称与Unit相同
+	Name      string           `json:"name,omitempty"`
 	Ports     []v1.ServicePort `json:"ports,omitempty" patchStrategy:"merge" patchMergeKey:"port" protobuf:"bytes,1,rep,name=ports"`
 	ClusterIP string           `json:"clusterIP,omitempty" protobuf:"bytes,3,opt,name=clusterIP"`
+
+	// ClusterIP / NodePort / LoadBalancer，默认ClusterIP。
+	// StatefulSet类型的Unit，此Service会作为StatefulSet的governing service，新建时设置为headless，只能使用ClusterIP
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	Type v1.ServiceType `json:"type,omitempty"`
+	// 仅NodePort/LoadBalancer类型有效
+	// +kubebuilder:validation:Enum=Cluster;Local
+	ExternalTrafficPolicy v1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+	// +kubebuilder:validation:Enum=ClientIP;None
+	SessionAffinity v1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+	// 仅LoadBalancer类型有效，限制访问LoadBalancer的来源网段
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// 原样透传到Service的annotations，例如云厂商LoadBalancer的配置
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// 在Unit的pod selector基础上追加的label，用于只选中部分pod
+	Selector map[string]string `json:"selector,omitempty"`
+	// 端口的主动健康检查，只检查这里列出的端口；
+	// 未列出的端口不做网络检查，根据endpoint就绪状态判断健康，至少有一个就绪的endpoint即视为健康
+	HealthChecks []ServicePortHealthCheck `json:"healthChecks,omitempty"`
+	// clusterIP创建后不可修改，已有Service的clusterIP与期望不一致(例如主Service需要切换为headless)时，
+	// 是否允许删除后重建。默认不重建，只设置Unit的ClusterIPMismatch条件并记录一次Warning Event；只会重建由此Unit管理的Service，重建期间Service不可用
+	AllowRecreate bool `json:"allowRecreate,omitempty"`
+
+	// 集群提供的EndpointSlice API版本，由controller设置，为空时从Endpoints获取endpoint状态
+	EndpointSliceAPIVersion string `json:"-"`
 }
 
 type ServicePortStatus struct {
 	v1.ServicePort `json:"servicePort,omitempty"`
-	// 检查此端口连通性
+	// 开启了主动检查时为最近一次检查的结果，否则为是否有就绪的endpoint提供此端口
 	Health bool `json:"health,omitempty"`
+	// 最近一次健康检查的时间和耗时，还没有检查过时为空
+	LastCheckTime       *metav1.Time `json:"lastCheckTime,omitempty"`
+	LatencyMilliseconds int64        `json:"latencyMilliseconds,omitempty"`
+	// 检查失败的原因
+	Message string `json:"message,omitempty"`
 }
 
 type UnitRelationServiceStatus struct {
+	// Service名称，serviceInfo中的主Service为空
+	Name            string              `json:"name,omitempty"`
 	Type            v1.ServiceType      `json:"type,omitempty"`
 	ClusterIP       string              `json:"clusterIP,omitempty"`
 	Ports           []ServicePortStatus `json:"ports,omitempty"`
 	SessionAffinity v1.ServiceAffinity  `json:"sessionAffinity,omitempty"`
+	// LoadBalancer类型的Service分配到的IP/域名
+	LoadBalancerIngress []v1.LoadBalancerIngress `json:"loadBalancerIngress,omitempty"`
 }
 
-type UnitRelationEndpointStatus struct {
-	PodName  string `json:"podName"`
-	PodIP    string `json:"podIP"`
-	NodeName string `json:"nodeName"`
+// 判断Service是否声明了此端口，port可以是端口号或端口名称
+func (ownService *OwnService) hasPort(port intstr.IntOrString) bool {
+	for _, servicePort := range ownService.Ports {
+		if port.Type == intstr.String && servicePort.Name == port.StrVal {
+			return true
+		}
+		if port.Type == intstr.Int && servicePort.Port == port.IntVal {
+			return true
+		}
+	}
+	return false
+}
+
+// 返回端口的主动健康检查配置，没有在healthChecks中开启时返回nil
+func (ownService *OwnService) PortHealthCheck(port v1.ServicePort) *ServicePortHealthCheck {
+	for i := range ownService.HealthChecks {
+		check := &ownService.HealthChecks[i]
+		if (check.Port.Type == intstr.String && check.Port.StrVal == port.Name) ||
+			(check.Port.Type == intstr.Int && check.Port.IntVal == port.Port) {
+			return check
+		}
+	}
+	return nil
+}
+
+// serviceInfo中的主Service与Unit同名，services中的Service以name作为后缀
+func (ownService *OwnService) ServiceName(instance *Unit) string {
+	if ownService.Name == "" {
+		return instance.Name
+	}
+	return instance.Name + "-" + ownService.Name
+}
+
+// StatefulSet类型的Unit，主Service作为governing service，需要是headless service
+func (ownService *OwnService) headless(instance *Unit) bool {
+	return ownService.Name == "" && instance.Spec.Category == CategoryStatefulSet
+}
+
+// 期望的clusterIP，未指定时为空，由集群分配。
+// StatefulSet的governing service需要是headless service，pod才能有稳定的DNS记录
+func (ownService *OwnService) ExpectedClusterIP(instance *Unit) string {
+	if ownService.headless(instance) {
+		return v1.ClusterIPNone
+	}
+	return ownService.ClusterIP
 }
 
 func (ownService *OwnService) MakeOwnResource(instance *Unit, logger logr.Logger,
@@ -56,26 +171,47 @@ func (ownService *OwnService) MakeOwnResource(instance *Unit, logger logr.Logger
 	// new a Service object
 	svc := &v1.Service{
 		// metadata field inherited from owner Unit
-		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace, Labels: instance.Labels},
+		ObjectMeta: metav1.ObjectMeta{Name: ownService.ServiceName(instance), Namespace: instance.Namespace,
+			Labels: instance.Labels, Annotations: ownService.Annotations},
 		Spec: v1.ServiceSpec{
-			Ports: ownService.Ports,
-			Type:  v1.ServiceTypeClusterIP,
+			Type:                     ownService.Type,
+			SessionAffinity:          ownService.SessionAffinity,
+			LoadBalancerSourceRanges: ownService.LoadBalancerSourceRanges,
 		},
 	}
+	if svc.Spec.Type == "" {
+		svc.Spec.Type = v1.ServiceTypeClusterIP
+	}
+	if svc.Spec.Type != v1.ServiceTypeClusterIP {
+		svc.Spec.ExternalTrafficPolicy = ownService.ExternalTrafficPolicy
+	}
 
-	if ownService.ClusterIP != "" {
-		svc.Spec.ClusterIP = ownService.ClusterIP
+	// server-side apply 时ports以port+protocol作为key，protocol需要显式指定
+	for _, port := range ownService.Ports {
+		if port.Protocol == "" {
+			port.Protocol = v1.ProtocolTCP
+		}
+		svc.Spec.Ports = append(svc.Spec.Ports, port)
 	}
 
-	// add selector
-	labelMap := make(map[string]string, 1)
-	labelMap["app"] = instance.Name
+	svc.Spec.ClusterIP = ownService.ExpectedClusterIP(instance)
+
+	// add selector，与Unit的pod label保持一致
+	labelMap := map[string]string{"app": instance.Name}
+	if instance.Spec.Selector != nil && len(instance.Spec.Selector.MatchLabels) > 0 {
+		labelMap = make(map[string]string, len(instance.Spec.Selector.MatchLabels)+len(ownService.Selector))
+		for k, v := range instance.Spec.Selector.MatchLabels {
+			labelMap[k] = v
+		}
+	}
+	for k, v := range ownService.Selector {
+		labelMap[k] = v
+	}
 	svc.Spec.Selector = labelMap
 
-	//svc.Spec.Selector =
 	// add ControllerReference for sts，the owner is Unit object
 	if err := controllerutil.SetControllerReference(instance, svc, scheme); err != nil {
-		msg := fmt.Sprintf("set controllerReference for Service %s/%s failed", instance.Namespace, instance.Name)
+		msg := fmt.Sprintf("set controllerReference for Service %s/%s failed", instance.Namespace, svc.Name)
 		logger.Error(err, msg)
 		return nil, err
 	}
@@ -88,12 +224,13 @@ func (ownService *OwnService) OwnResourceExist(instance *Unit, client client.Cli
 	logger logr.Logger) (bool, interface{}, error) {
 
 	found := &v1.Service{}
-	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	name := ownService.ServiceName(instance)
+	err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: instance.Namespace}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return false, nil, nil
 		}
-		msg := fmt.Sprintf("Service %s/%s found, but with error", instance.Namespace, instance.Name)
+		msg := fmt.Sprintf("Service %s/%s found, but with error", instance.Namespace, name)
 		logger.Error(err, msg)
 		return true, found, err
 	}
@@ -106,57 +243,46 @@ func (ownService *OwnService) UpdateOwnResourceStatus(instance *Unit, client cli
 
 	// 更新Service status
 	found := &v1.Service{}
-	err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	err := client.Get(context.TODO(), types.NamespacedName{Name: ownService.ServiceName(instance), Namespace: instance.Namespace}, found)
 	if err != nil {
 		return instance, err
 	}
 
+	// 端口默认根据endpoint就绪状态判断健康；
+	// 开启了主动检查的端口由controller在后台异步执行，结果在调谐时从缓存中填充
+	endpointsStatus, err := ownService.EndpointsStatus(instance, client)
+	if err != nil {
+		return instance, err
+	}
 	var portsStatus []ServicePortStatus
 	for _, port := range found.Spec.Ports {
-		// 对 service的每一个端口进行健康检查，并在status中加上健康状态的字段
-		health := true
-		checkPort := port.Port
-		addr := found.Spec.ClusterIP
-		sock := fmt.Sprintf("%s:%d", addr, checkPort)
-		proto := string(port.Protocol)
-		_, err := net.DialTimeout(proto, sock, time.Duration(100)*time.Millisecond)
-		if err != nil {
-			health = false
-		}
-
-		portStatus := ServicePortStatus{
-			ServicePort: port,
-			Health:      health,
+		portStatus := ServicePortStatus{ServicePort: port}
+		if _, _, ok := ReadyEndpointPort(endpointsStatus, port); ok {
+			portStatus.Health = true
+		} else {
+			portStatus.Message = "no ready endpoint"
 		}
 		portsStatus = append(portsStatus, portStatus)
 	}
 
 	serviceStatus := UnitRelationServiceStatus{
-		Type:      found.Spec.Type,
-		ClusterIP: found.Spec.ClusterIP,
-		Ports:     portsStatus,
+		Name:                ownService.Name,
+		Type:                found.Spec.Type,
+		ClusterIP:           found.Spec.ClusterIP,
+		Ports:               portsStatus,
+		SessionAffinity:     found.Spec.SessionAffinity,
+		LoadBalancerIngress: found.Status.LoadBalancer.Ingress,
+	}
+	if ownService.Name != "" {
+		// services中的Service，只记录Service自身的状态
+		instance.Status.RelationResourceStatus.Services = append(instance.Status.RelationResourceStatus.Services, serviceStatus)
+		instance.Status.LastUpdateTime = metav1.Now()
+		return instance, nil
 	}
 	instance.Status.RelationResourceStatus.Service = serviceStatus
 
 	// 更新Endpoint status
-	foundEndpoint := &v1.Endpoints{}
-	err = client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, foundEndpoint)
-	if err != nil {
-		return instance, err
-	}
-
-	if foundEndpoint.Subsets != nil && foundEndpoint.Subsets[0].Addresses != nil {
-		var endpointsStatus []UnitRelationEndpointStatus
-		for _, ep := range foundEndpoint.Subsets[0].Addresses {
-			endpointStatus := UnitRelationEndpointStatus{
-				PodName:  ep.Hostname,
-				PodIP:    ep.IP,
-				NodeName: *ep.NodeName,
-			}
-			endpointsStatus = append(endpointsStatus, endpointStatus)
-		}
-		instance.Status.RelationResourceStatus.Endpoint = endpointsStatus
-	}
+	instance.Status.RelationResourceStatus.Endpoint = endpointsStatus
 
 	// update LastUpdateTime
 	instance.Status.LastUpdateTime = metav1.Now()
@@ -166,10 +292,10 @@ func (ownService *OwnService) UpdateOwnResourceStatus(instance *Unit, client cli
 
 // apply this own resource, create or update
 func (ownService *OwnService) ApplyOwnResource(instance *Unit, client client.Client,
-	logger logr.Logger, scheme *runtime.Scheme) error {
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
 
 	// assert if Service exist
-	exist, found, err := ownService.OwnResourceExist(instance, client, logger)
+	_, found, err := ownService.OwnResourceExist(instance, client, logger)
 	if err != nil {
 		return err
 	}
@@ -181,25 +307,28 @@ func (ownService *OwnService) ApplyOwnResource(instance *Unit, client client.Cli
 	}
 	newService := sts.(*v1.Service)
 
-	// apply the Service object just make
-	if !exist {
-		// if Service not exist，then create it
-		msg := fmt.Sprintf("Service %s/%s not found, create it!", newService.Namespace, newService.Name)
-		logger.Info(msg)
-		return client.Create(context.TODO(), newService)
-	} else {
+	// clusterIP创建后不可修改，只有新建的Service才会按期望设置clusterIP(包括StatefulSet主Service的headless)。
+	// 已有Service不一致时，显式开启allowRecreate且Service由此Unit管理才删除重建，
+	// 否则保留原clusterIP，由controller根据Service status记录ClusterIPMismatch条件
+	if found != nil {
 		foundService := found.(*v1.Service)
-
-		// 这里有个坑，svc在创建前可能未指定clusterIP，那么svc创建后，会自动指定clusterIP并修改spec.clusterIP字段，因此这里要补上。SessionAffinity同理
-		newService.Spec.ClusterIP = foundService.Spec.ClusterIP
-		newService.Spec.SessionAffinity = foundService.Spec.SessionAffinity
-
-		// if Service exist with change，then try to update it
-		if !reflect.DeepEqual(newService.Spec, foundService.Spec) {
-			msg := fmt.Sprintf("Updating Service %s/%s", newService.Namespace, newService.Name)
-			logger.Info(msg)
-			return client.Update(context.TODO(), newService)
+		if newService.Spec.ClusterIP != "" && foundService.Spec.ClusterIP != newService.Spec.ClusterIP {
+			if !ownService.AllowRecreate || !metav1.IsControlledBy(foundService, instance) {
+				newService.Spec.ClusterIP = ""
+			} else {
+				msg := fmt.Sprintf("Service %s/%s clusterIP changed from %s to %s, recreate it", instance.Namespace,
+					newService.Name, foundService.Spec.ClusterIP, newService.Spec.ClusterIP)
+				logger.Info(msg)
+				if err := client.Delete(context.TODO(), foundService); err != nil && !errors.IsNotFound(err) {
+					recordOwnResourceEvent(instance, recorder, "delete", EventReasonFailed, "Service", foundService, err)
+					return err
+				}
+				recordOwnResourceEvent(instance, recorder, "delete", EventReasonDeleted, "Service", foundService, nil)
+				found = nil
+			}
 		}
-		return nil
 	}
+
+	// apply the Service object just make，通过server-side apply 创建或更新，只管理Unit指定的字段
+	return applyOwnResource(instance, client, logger, scheme, recorder, "Service", newService, found)
 }
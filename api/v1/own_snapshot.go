@@ -0,0 +1,68 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// VolumeSnapshot的API版本，controller不依赖external-snapshotter的Go类型，统一使用unstructured对象
+const (
+	VolumeSnapshotGroup             string = "snapshot.storage.k8s.io"
+	VolumeSnapshotAPIVersionV1      string = "snapshot.storage.k8s.io/v1"
+	VolumeSnapshotAPIVersionV1beta1 string = "snapshot.storage.k8s.io/v1beta1"
+	VolumeSnapshotKind              string = "VolumeSnapshot"
+
+	// Unit创建的VolumeSnapshot带上此label，值为Unit名称
+	UnitNameLabel string = "custom.my.crd.com/unit"
+	// 定时备份创建的VolumeSnapshot带上此label，只有这些快照会按retain数量清理
+	BackupLabel string = "custom.my.crd.com/backup"
+)
+
+// 定时备份创建的VolumeSnapshot
+type UnitVolumeSnapshotStatus struct {
+	Name string `json:"name"`
+	// 被备份的PVC，以及它在spec.relationResource.volumes中的volume名称(pvcInfo为空)
+	ClaimName    string      `json:"claimName"`
+	VolumeName   string      `json:"volumeName,omitempty"`
+	CreationTime metav1.Time `json:"creationTime,omitempty"`
+	ReadyToUse   bool        `json:"readyToUse"`
+	RestoreSize  string      `json:"restoreSize,omitempty"`
+	// 快照创建失败的原因
+	Error string `json:"error,omitempty"`
+}
+
+// 生成对PVC的VolumeSnapshot，snapshotClassName为空时使用集群默认的VolumeSnapshotClass
+func NewVolumeSnapshot(apiVersion, namespace, name, claimName string, snapshotClassName *string,
+	labels map[string]string) *unstructured.Unstructured {
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetAPIVersion(apiVersion)
+	snapshot.SetKind(VolumeSnapshotKind)
+	snapshot.SetNamespace(namespace)
+	snapshot.SetName(name)
+	snapshot.SetLabels(labels)
+
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": claimName},
+	}
+	if snapshotClassName != nil {
+		spec["volumeSnapshotClassName"] = *snapshotClassName
+	}
+	snapshot.Object["spec"] = spec
+	return snapshot
+}
+
+// 快照来源的PVC名称
+func VolumeSnapshotSource(snapshot *unstructured.Unstructured) string {
+	claimName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	return claimName
+}
+
+// 返回VolumeSnapshot是否已可用，创建失败时返回status.error中的信息
+func VolumeSnapshotReady(snapshot *unstructured.Unstructured) (bool, string) {
+	if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found {
+		return false, message
+	}
+	ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	return ready, ""
+}
@@ -5,12 +5,11 @@ import (
 	"fmt"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
-	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"reflect"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -29,33 +28,6 @@ func (ownStatefulSet *OwnStatefulSet) MakeOwnResource(instance *Unit, logger log
 		Spec:       ownStatefulSet.Spec,
 	}
 
-	// add some customize envs, ignore this step if you don't need it
-	customizeEnvs := []v1.EnvVar{
-		{
-			Name: "POD_NAME",
-			ValueFrom: &v1.EnvVarSource{
-				FieldRef: &v1.ObjectFieldSelector{
-					APIVersion: "v1",
-					FieldPath:  "metadata.name",
-				},
-			},
-		},
-		{
-			Name:  "APPNAME",
-			Value: instance.Name,
-		},
-	}
-
-	var specEnvs []v1.EnvVar
-	templateEnvs := sts.Spec.Template.Spec.Containers[0].Env
-	for index := range templateEnvs {
-		if templateEnvs[index].Name != "POD_NAME" && templateEnvs[index].Name != "APPNAME" {
-			specEnvs = append(specEnvs, templateEnvs[index])
-		}
-	}
-
-	sts.Spec.Template.Spec.Containers[0].Env = append(specEnvs, customizeEnvs...)
-
 	// add ControllerReference for sts，the owner is Unit object
 	if err := controllerutil.SetControllerReference(instance, sts, scheme); err != nil {
 		msg := fmt.Sprintf("set controllerReference for StatefulSet %s/%s failed", instance.Namespace, instance.Name)
@@ -92,6 +64,20 @@ func (ownStatefulSet *OwnStatefulSet) UpdateOwnResourceStatus(instance *Unit, cl
 		return instance, err
 	}
 	instance.Status.BaseStatefulSet = found.Status
+	if err := setScaleStatus(instance, found.Status.Replicas, found.Spec.Selector); err != nil {
+		msg := fmt.Sprintf("convert StatefulSet %s/%s selector error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return instance, err
+	}
+
+	// volumeClaimTemplates为每个副本创建的PVC
+	volumesStatus, err := volumeClaimTemplatesStatus(instance, client)
+	if err != nil {
+		msg := fmt.Sprintf("list StatefulSet %s/%s PVC error", instance.Namespace, instance.Name)
+		logger.Error(err, msg)
+		return instance, err
+	}
+	instance.Status.RelationResourceStatus.Volumes = volumesStatus
 	instance.Status.LastUpdateTime = metav1.Now()
 	return instance, nil
 
@@ -104,10 +90,10 @@ func (ownStatefulSet *OwnStatefulSet) UpdateOwnResourceStatus(instance *Unit, cl
 
 // apply this own resource, create or update
 func (ownStatefulSet *OwnStatefulSet) ApplyOwnResource(instance *Unit, client client.Client,
-	logger logr.Logger, scheme *runtime.Scheme) error {
+	logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error {
 
 	// assert if StatefulSet exist
-	exist, found, err := ownStatefulSet.OwnResourceExist(instance, client, logger)
+	_, found, err := ownStatefulSet.OwnResourceExist(instance, client, logger)
 	if err != nil {
 		return err
 	}
@@ -119,25 +105,15 @@ func (ownStatefulSet *OwnStatefulSet) ApplyOwnResource(instance *Unit, client cl
 	}
 	newStatefulSet := sts.(*appsv1.StatefulSet)
 
-	// apply the StatefulSet object just make
-	if !exist {
-		// if StatefulSet not exist，then create it
-		msg := fmt.Sprintf("StatefulSet %s/%s not found, create it!", newStatefulSet.Namespace, newStatefulSet.Name)
-		logger.Info(msg)
-		if err := client.Create(context.TODO(), newStatefulSet); err != nil {
-			return err
-		}
-		return nil
-
-	} else {
-		foundStatefulSet := found.(*appsv1.StatefulSet)
+	// volumeClaimTemplates创建后不可修改，已存在的StatefulSet沿用原有的templates，扩容直接作用于每个副本的PVC，
+	// 包括扩容之后新增的副本，它们的PVC按原有的templates创建，在之后的调谐中扩容
+	if found != nil {
+		newStatefulSet.Spec.VolumeClaimTemplates = found.(*appsv1.StatefulSet).Spec.VolumeClaimTemplates
+	}
 
-		// if StatefulSet exist with change，then try to update it
-		if !reflect.DeepEqual(newStatefulSet.Spec, foundStatefulSet.Spec) {
-			msg := fmt.Sprintf("Updating StatefulSet %s/%s", newStatefulSet.Namespace, newStatefulSet.Name)
-			logger.Info(msg)
-			return client.Update(context.TODO(), newStatefulSet)
-		}
-		return nil
+	// apply the StatefulSet object just make，通过server-side apply 创建或更新，只管理Unit指定的字段
+	if err := applyOwnResource(instance, client, logger, scheme, recorder, "StatefulSet", newStatefulSet, found); err != nil {
+		return err
 	}
+	return expandVolumeClaimTemplates(instance, client, logger, recorder)
 }
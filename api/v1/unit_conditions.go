@@ -0,0 +1,57 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 按type查找Unit的状态条件，不存在时返回nil
+func (status *UnitStatus) GetCondition(conditionType string) *UnitCondition {
+	for index := range status.Conditions {
+		if status.Conditions[index].Type == conditionType {
+			return &status.Conditions[index]
+		}
+	}
+	return nil
+}
+
+// 判断指定type的状态条件是否为True
+func (status *UnitStatus) IsConditionTrue(conditionType string) bool {
+	condition := status.GetCondition(conditionType)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+
+// 设置Unit的状态条件，只有status发生变化时才刷新LastTransitionTime
+func (status *UnitStatus) SetCondition(newCondition UnitCondition) {
+	existing := status.GetCondition(newCondition.Type)
+	if existing == nil {
+		if newCondition.LastTransitionTime.IsZero() {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		status.Conditions = append(status.Conditions, newCondition)
+		return
+	}
+
+	if existing.Status != newCondition.Status {
+		existing.Status = newCondition.Status
+		if newCondition.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		} else {
+			existing.LastTransitionTime = newCondition.LastTransitionTime
+		}
+	}
+	existing.Reason = newCondition.Reason
+	existing.Message = newCondition.Message
+	existing.ObservedGeneration = newCondition.ObservedGeneration
+}
+
+// 删除指定type的状态条件
+func (status *UnitStatus) RemoveCondition(conditionType string) {
+	var conditions []UnitCondition
+	for _, condition := range status.Conditions {
+		if condition.Type != conditionType {
+			conditions = append(conditions, condition)
+		}
+	}
+	status.Conditions = conditions
+}
@@ -18,61 +18,299 @@ package v1
 
 import (
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
-// 与Unit关联的own build-in资源(svc/ing/pvc)指定
+// 与Unit关联的own build-in资源(svc/ing/pvc/pdb/httproute)指定
 type UnitRelationResourceSpec struct {
 	Service *OwnService `json:"serviceInfo,omitempty"`
-	PVC     *OwnPVC     `json:"pvcInfo,omitempty"`
+	// 额外的Service，例如对外的LoadBalancer和对内的管理/监控端口分开暴露，name必须指定且不能重复
+	Services []OwnService `json:"services,omitempty"`
+	PVC      *OwnPVC      `json:"pvcInfo,omitempty"`
+	// 挂载到pod中的持久化存储，name必须指定且不能重复
+	Volumes []OwnPVC    `json:"volumes,omitempty"`
 	Ingress *OwnIngress `json:"ingressInfo,omitempty"`
+	PDB     *OwnPDB     `json:"pdbInfo,omitempty"`
+	// Gateway API HTTPRoute，可以替代Ingress
+	GatewayRoute *OwnGatewayRoute `json:"gatewayRoute,omitempty"`
 }
 
 const (
 	CategoryDeployment  string = "Deployment"
 	CategoryStatefulSet string = "StatefulSet"
+	CategoryDaemonSet   string = "DaemonSet"
+	CategoryJob         string = "Job"
+	CategoryCronJob     string = "CronJob"
 )
 
+// Job/CronJob 类型的Unit的任务配置
+type UnitBatchSpec struct {
+	// CronJob的调度周期，标准的5段cron表达式，仅CronJob类型有效，在admission validating webhook里会做校验
+	Schedule string `json:"schedule,omitempty"`
+
+	// CronJob上一次执行未结束时，新一轮调度的处理策略
+	// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+	ConcurrencyPolicy batchv1beta1.ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// 暂停CronJob的后续调度，不影响已在运行中的Job
+	Suspend *bool `json:"suspend,omitempty"`
+
+	// Job失败重试的次数
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// CronJob保留的成功/失败Job的历史数量
+	SuccessfulJobsHistoryLimit *int32 `json:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int32 `json:"failedJobsHistoryLimit,omitempty"`
+}
+
+// Unit的水平自动扩缩容配置，Unit会创建一个以Unit自身(scale subresource)为目标的HPA
+type UnitAutoscalingSpec struct {
+	// 扩缩容的副本数范围，minReplicas默认为1
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// pod平均CPU/内存使用率(相对于requests)的目标百分比
+	TargetCPUUtilizationPercentage    *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+
+	// 自定义指标(Pods/Object/External等)，原样透传给HPA
+	Metrics []autoscalingv2beta2.MetricSpec `json:"metrics,omitempty"`
+}
+
 // UnitSpec defines the desired state of Unit
 type UnitSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Category 支持两种: Deployment / StatefulSet ，在admission validating webhook里会做校验
+	// Category 支持: Deployment / StatefulSet / DaemonSet / Job / CronJob ，在admission validating webhook里会做校验
 	Category string `json:"category"`
 
-	// Replicas和Selector这两个字段在mutate webhook里默认会有填充
+	// Replicas和Selector这两个字段在mutate webhook里默认会有填充，DaemonSet/Job/CronJob类型不填充Replicas，也不允许指定Replicas
 	Replicas *int32                `json:"replicas,omitempty"`
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 
 	// Template describes the pods that will be created.
 	Template         corev1.PodTemplateSpec   `json:"template"`
 	RelationResource UnitRelationResourceSpec `json:"relationResource,omitempty"`
+
+	// Job/CronJob类型的任务配置
+	Batch *UnitBatchSpec `json:"batch,omitempty"`
+
+	// 水平自动扩缩容配置，仅Deployment/StatefulSet类型有效，开启后spec.replicas由HPA调整
+	Autoscaling *UnitAutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// 删除Unit时如何处理它创建的对象，默认为Delete：
+	// Delete 随Unit一起删除；Orphan 去掉对象上Unit的ownerReference，工作负载继续运行；
+	// Protect 拒绝删除Unit，除非Unit带有 custom.my.crd.com/confirm-delete: "true" annotation
+	// +kubebuilder:validation:Enum=Delete;Orphan;Protect
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// 删除前的优雅下线配置，需要显式开启：不指定时不做下线，删除Unit会立即删除所有对象；
+	// 指定 drain: {} 即按默认的periodSeconds/timeoutSeconds下线。deletionPolicy为Orphan时不做下线
+	Drain *UnitDrainSpec `json:"drain,omitempty"`
+
+	// 注入到容器中的环境变量(pod名称、namespace、节点、pod IP、Unit名称、版本)，
+	// 不指定时向所有容器注入POD_NAME和APPNAME
+	InjectEnv *UnitInjectEnvSpec `json:"injectEnv,omitempty"`
 }
 
+// Unit删除前的优雅下线配置：先删除Ingress/HTTPRoute摘掉外部流量，等待drain period后将工作负载缩容到0，
+// 等待Service的endpoints清空后再继续删除
+type UnitDrainSpec struct {
+	// 删除Ingress后、缩容之前的等待时间，给负载均衡和客户端留出切换的时间，默认30s
+	// +kubebuilder:validation:Minimum=0
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+	// 缩容后等待endpoints清空的最长时间，超时后不再等待，默认300s
+	// +kubebuilder:validation:Minimum=0
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// Unit删除前优雅下线的阶段
+const (
+	// Ingress/HTTPRoute已删除，等待drain period
+	DrainPhaseIngressRemoved string = "IngressRemoved"
+	// 工作负载已缩容到0，等待endpoints清空
+	DrainPhaseScaledDown string = "ScaledDown"
+	DrainPhaseCompleted  string = "Completed"
+)
+
+type UnitDrainStatus struct {
+	// IngressRemoved / ScaledDown / Completed
+	Phase   string `json:"phase"`
+	Message string `json:"message,omitempty"`
+	// 进入当前阶段的时间
+	PhaseStartTime metav1.Time `json:"phaseStartTime,omitempty"`
+}
+
+// Unit的删除策略
+const (
+	DeletionPolicyDelete  string = "Delete"
+	DeletionPolicyOrphan  string = "Orphan"
+	DeletionPolicyProtect string = "Protect"
+
+	// deletionPolicy为Protect时，需要先为Unit加上此annotation且值为"true"才能删除
+	DeleteConfirmAnnotation string = "custom.my.crd.com/confirm-delete"
+)
+
 type UnitRelationResourceStatus struct {
-	Service  UnitRelationServiceStatus          `json:"service,omitempty"`
-	Ingress  []v1beta1.IngressRule              `json:"ingress,omitempty"`
+	Service UnitRelationServiceStatus `json:"service,omitempty"`
+	// spec.relationResource.services中各Service的状态
+	Services []UnitRelationServiceStatus        `json:"services,omitempty"`
+	Ingress  []UnitIngressRuleStatus            `json:"ingress,omitempty"`
 	Endpoint []UnitRelationEndpointStatus       `json:"endpoint,omitempty"`
 	PVC      corev1.PersistentVolumeClaimStatus `json:"pvc,omitempty"`
+	// spec.relationResource.volumes对应的各个PVC的状态
+	Volumes []UnitVolumeStatus    `json:"volumes,omitempty"`
+	PDB     UnitRelationPDBStatus `json:"pdb,omitempty"`
+	// HTTPRoute在各个Gateway上的状态
+	GatewayRoute []UnitGatewayRouteParentStatus `json:"gatewayRoute,omitempty"`
+}
+
+// 关联资源从spec中移除后的处理方式
+const (
+	RemovedResourceDeleted string = "Deleted"
+	RemovedResourceKept    string = "Kept"
+
+	// 关联资源从spec中移除后默认会被删除，带有此annotation且值为"true"的资源会保留，并去掉Unit的ownerReference
+	KeepResourceAnnotation string = "custom.my.crd.com/keep"
+)
+
+// Unit创建的一个对象，kubectl describe unit 可以看到Unit管理的全部对象
+type UnitInventoryEntry struct {
+	Group   string    `json:"group,omitempty"`
+	Version string    `json:"version"`
+	Kind    string    `json:"kind"`
+	Name    string    `json:"name"`
+	UID     types.UID `json:"uid,omitempty"`
+}
+
+// 从spec中移除的关联资源的处理记录
+type UnitRemovedResource struct {
+	// 资源的Kind，与inventory一致，例如 Service / Ingress / HTTPRoute / HorizontalPodAutoscaler / PodDisruptionBudget
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Deleted / Kept
+	Action  string      `json:"action"`
+	Time    metav1.Time `json:"time,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
+
+// Unit 所属Job的一次执行记录
+type UnitJobStatus struct {
+	Name string `json:"name"`
+	// Running / Succeeded / Failed
+	Phase string `json:"phase,omitempty"`
+	// Job还未开始/结束时为空
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	Active         int32        `json:"active,omitempty"`
+	Succeeded      int32        `json:"succeeded,omitempty"`
+	Failed         int32        `json:"failed,omitempty"`
+}
+
+const (
+	MigrationPhaseWaitingForReady string = "WaitingForReady"
+	MigrationPhaseCompleted       string = "Completed"
+)
+
+// spec.category 在Deployment/StatefulSet/DaemonSet之间变更时，工作负载迁移的进度
+type UnitMigrationStatus struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// WaitingForReady: 新的工作负载已创建，等待其就绪; Completed: 旧的工作负载已删除
+	Phase          string      `json:"phase"`
+	Message        string      `json:"message,omitempty"`
+	StartTime      metav1.Time `json:"startTime,omitempty"`
+	CompletionTime metav1.Time `json:"completionTime,omitempty"`
+}
+
+const (
+	// 工作负载的pod已全部就绪，Job类型为执行成功，CronJob类型为已按周期调度
+	ConditionAvailable string = "Available"
+	// 工作负载正在滚动更新/扩缩容/迁移，或Job正在执行
+	ConditionProgressing string = "Progressing"
+	// 工作负载未就绪且不在更新中，或Job执行失败，或调谐过程出错
+	ConditionDegraded string = "Degraded"
+	// 调谐own resource时出错，message中记录出错的own resource类型，
+	// 每一类own resource的错误信息记录在各自的<Kind>Ready条件中，例如 DeploymentReady / ServiceReady
+	ConditionReconcileError string = "ReconcileError"
+	// 已有Service的clusterIP与spec不一致，且没有开启allowRecreate，message中记录不一致的Service
+	ConditionClusterIPMismatch string = "ClusterIPMismatch"
+)
+
+const (
+	UnitPhasePending     string = "Pending"
+	UnitPhaseProgressing string = "Progressing"
+	UnitPhaseRunning     string = "Running"
+	UnitPhaseSucceeded   string = "Succeeded"
+	UnitPhaseDegraded    string = "Degraded"
+	UnitPhaseFailed      string = "Failed"
+)
+
+// Unit的状态条件，字段与metav1.Condition保持一致，便于kubectl wait --for=condition=Available 等工具使用
+type UnitCondition struct {
+	// Available / Progressing / Degraded / ReconcileError / ClusterIPMismatch / <Kind>Ready
+	Type   string                 `json:"type"`
+	Status corev1.ConditionStatus `json:"status"`
+	// 设置此条件时Unit的metadata.generation
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
 }
 
 // UnitStatus defines the observed state of Unit
 type UnitStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+	// Replicas和Selector供scale subresource使用，由工作负载的状态填充，HPA据此计算副本数
 	Replicas       *int32      `json:"replicas,omitempty"`
 	Selector       string      `json:"selector"`
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 
+	// controller最近一次处理的Unit metadata.generation
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Pending / Progressing / Running / Succeeded / Degraded / Failed ，由conditions计算得出
+	Phase      string          `json:"phase,omitempty"`
+	Conditions []UnitCondition `json:"conditions,omitempty"`
+
 	BaseDeployment         appsv1.DeploymentStatus    `json:"deployment,omitempty"`
 	BaseStatefulSet        appsv1.StatefulSetStatus   `json:"statefulSet,omitempty"`
+	BaseDaemonSet          appsv1.DaemonSetStatus     `json:"daemonSet,omitempty"`
+	BaseJob                batchv1.JobStatus          `json:"job,omitempty"`
+	BaseCronJob            batchv1beta1.CronJobStatus `json:"cronJob,omitempty"`
 	RelationResourceStatus UnitRelationResourceStatus `json:"relationResourceStatus,omitempty"`
+
+	// Unit所属HPA的状态
+	Autoscaling *autoscalingv2beta2.HorizontalPodAutoscalerStatus `json:"autoscaling,omitempty"`
+
+	// Job/CronJob 的执行历史，按开始时间倒序
+	JobHistory []UnitJobStatus `json:"jobHistory,omitempty"`
+
+	// 最近一次工作负载迁移的进度
+	Migration *UnitMigrationStatus `json:"migration,omitempty"`
+
+	// PVC定时备份创建的VolumeSnapshot，按创建时间从新到旧排列
+	Backups []UnitVolumeSnapshotStatus `json:"backups,omitempty"`
+
+	// 从spec中移除后被清理(或按annotation保留)的关联资源，最新的在前，最多10条
+	RemovedResources []UnitRemovedResource `json:"removedResources,omitempty"`
+
+	// Unit创建并管理的所有对象，每次ApplyOwnResource成功后更新，用于清理spec不再生成的对象
+	Inventory []UnitInventoryEntry `json:"inventory,omitempty"`
+
+	// Unit删除前优雅下线的进度
+	Drain *UnitDrainStatus `json:"drain,omitempty"`
 }
 
 // +kubebuilder:object:root=true
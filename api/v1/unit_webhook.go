@@ -18,11 +18,18 @@ package v1
 
 import (
 	"errors"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"strings"
 )
 
 // log is for logging in this package.
@@ -50,11 +57,33 @@ func (r *Unit) Default() {
 	// default replicas set to 1
 	unitlog.Info("default", "name", r.Name)
 
-	if r.Spec.Replicas == nil {
+	// DaemonSet 每个节点运行一个pod，Job/CronJob 按任务执行，replicas都无意义，不做默认填充
+	if r.Spec.Replicas == nil && !r.replicasIgnored() {
 		defaultReplicas := int32(1)
+		// 开启自动扩缩容时，初始副本数取minReplicas
+		if r.Spec.Autoscaling != nil && r.Spec.Autoscaling.MinReplicas != nil {
+			defaultReplicas = *r.Spec.Autoscaling.MinReplicas
+		}
 		r.Spec.Replicas = &defaultReplicas
 	}
 
+	// Job的pod不允许使用默认的Always重启策略
+	if r.isBatch() && r.Spec.Template.Spec.RestartPolicy == "" {
+		r.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+	}
+
+	// PDB未指定minAvailable和maxUnavailable时，默认每次最多驱逐一个pod。DaemonSet不支持PDB，由validate拒绝
+	if pdb := r.Spec.RelationResource.PDB; pdb != nil && pdb.MinAvailable == nil && pdb.MaxUnavailable == nil &&
+		r.Spec.Category != CategoryDaemonSet {
+		defaultMaxUnavailable := intstr.FromInt(1)
+		pdb.MaxUnavailable = &defaultMaxUnavailable
+	}
+
+	// 默认随Unit一起删除它创建的对象
+	if r.Spec.DeletionPolicy == "" {
+		r.Spec.DeletionPolicy = DeletionPolicyDelete
+	}
+
 	// add default selector label
 	labelMap := make(map[string]string, 1)
 	labelMap["app"] = r.Name
@@ -72,8 +101,8 @@ func (r *Unit) Default() {
 
 }
 
-// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
-// +kubebuilder:webhook:verbs=create;update,path=/validate-custom-my-crd-com-v1-unit,mutating=false,failurePolicy=fail,groups=custom.my.crd.com,resources=units,versions=v1,name=vunit.kb.io
+// deletionPolicy为Protect时需要在删除时校验
+// +kubebuilder:webhook:verbs=create;update;delete,path=/validate-custom-my-crd-com-v1-unit,mutating=false,failurePolicy=fail,groups=custom.my.crd.com,resources=units,versions=v1,name=vunit.kb.io
 
 var _ webhook.Validator = &Unit{}
 
@@ -83,17 +112,7 @@ func (r *Unit) ValidateCreate() error {
 
 	// TODO(user): fill in your validation logic upon object creation.
 
-	// 检查Unit.Spec.Category
-	switch r.Spec.Category {
-	case CategoryDeployment:
-		return nil
-	case CategoryStatefulSet:
-		return nil
-	default:
-		err := errors.New("spec.category only support Deployment or StatefulSet")
-		unitlog.Error(err, "creating validate failed", "name", r.Name)
-		return err
-	}
+	return r.validateUnit(nil)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -101,13 +120,513 @@ func (r *Unit) ValidateUpdate(old runtime.Object) error {
 	unitlog.Info("validate update", "name", r.Name)
 
 	// TODO(user): fill in your validation logic upon object update.
-	return nil
+	// category 只能在Deployment/StatefulSet/DaemonSet之间变更，由controller完成新旧工作负载的迁移
+	oldUnit, ok := old.(*Unit)
+	if ok && oldUnit.Spec.Category != r.Spec.Category && (oldUnit.isBatch() || r.isBatch()) {
+		err := fmt.Errorf("spec.category can not be changed from %s to %s, only Deployment, StatefulSet and DaemonSet can migrate to each other",
+			oldUnit.Spec.Category, r.Spec.Category)
+		unitlog.Error(err, "validate failed", "name", r.Name)
+		return err
+	}
+
+	// Job的pod模板创建后不可修改，Job只创建一次，这些字段的变更不会生效，直接拒绝
+	if ok && oldUnit.Spec.Category == CategoryJob && r.Spec.Category == CategoryJob {
+		if err := r.validateJobUpdate(oldUnit); err != nil {
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	}
+
+	// PVC创建后只能扩容
+	if ok {
+		if err := r.validateVolumesUpdate(oldUnit); err != nil {
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	}
+
+	return r.validateUnit(oldUnit)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (r *Unit) ValidateDelete() error {
 	unitlog.Info("validate delete", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object deletion.
+	// deletionPolicy为Protect的Unit，需要先加上确认删除的annotation，避免误删
+	if r.Spec.DeletionPolicy == DeletionPolicyProtect && r.Annotations[DeleteConfirmAnnotation] != "true" {
+		err := fmt.Errorf("Unit %s/%s is protected by spec.deletionPolicy Protect, set annotation %s: \"true\" to confirm the deletion",
+			r.Namespace, r.Name, DeleteConfirmAnnotation)
+		unitlog.Error(err, "validate failed", "name", r.Name)
+		return err
+	}
+	return nil
+}
+
+// Unit创建和更新时共用的校验逻辑，创建时old为nil
+func (r *Unit) validateUnit(old *Unit) error {
+	// 检查Unit.Spec.Category
+	switch r.Spec.Category {
+	case CategoryDeployment, CategoryStatefulSet:
+	case CategoryDaemonSet, CategoryJob, CategoryCronJob:
+		// DaemonSet 的副本数由节点数决定，Job/CronJob 按任务执行，都不允许指定replicas
+		if r.Spec.Replicas != nil {
+			err := fmt.Errorf("spec.replicas is not allowed when spec.category is %s", r.Spec.Category)
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	default:
+		err := errors.New("spec.category only support Deployment, StatefulSet, DaemonSet, Job or CronJob")
+		unitlog.Error(err, "validate failed", "name", r.Name)
+		return err
+	}
+
+	// 检查自动扩缩容配置
+	if autoscaling := r.Spec.Autoscaling; autoscaling != nil {
+		if r.replicasIgnored() {
+			err := fmt.Errorf("spec.autoscaling is not allowed when spec.category is %s", r.Spec.Category)
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+		if autoscaling.MinReplicas != nil && *autoscaling.MinReplicas > autoscaling.MaxReplicas {
+			err := fmt.Errorf("spec.autoscaling.minReplicas %d must be less than or equal to maxReplicas %d",
+				*autoscaling.MinReplicas, autoscaling.MaxReplicas)
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	}
+
+	// 检查PDB配置
+	if pdb := r.Spec.RelationResource.PDB; pdb != nil {
+		// DaemonSet没有scale subresource，disruption controller无法计算maxUnavailable，且kubectl drain不会驱逐DaemonSet的pod
+		if r.isBatch() || r.Spec.Category == CategoryDaemonSet {
+			err := fmt.Errorf("spec.relationResource.pdbInfo is not allowed when spec.category is %s", r.Spec.Category)
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+		if pdb.MinAvailable != nil && pdb.MaxUnavailable != nil {
+			err := errors.New("spec.relationResource.pdbInfo minAvailable and maxUnavailable can not be both specified")
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	}
+
+	// 检查Service配置
+	if service := r.Spec.RelationResource.Service; service != nil {
+		if service.Name != "" {
+			err := errors.New("spec.relationResource.serviceInfo.name is not allowed, the service is named after the Unit")
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+		if err := r.validateService("spec.relationResource.serviceInfo", service); err != nil {
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	}
+	serviceNames := make(map[string]bool)
+	for i := range r.Spec.RelationResource.Services {
+		service := &r.Spec.RelationResource.Services[i]
+		if err := r.validateNamedService(service, serviceNames); err != nil {
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	}
+
+	// 检查环境变量注入配置
+	if err := r.validateInjectEnv(); err != nil {
+		unitlog.Error(err, "validate failed", "name", r.Name)
+		return err
+	}
+
+	// 检查volumes配置
+	if err := r.validateVolumes(); err != nil {
+		unitlog.Error(err, "validate failed", "name", r.Name)
+		return err
+	}
+
+	// 检查Ingress配置
+	if ingress := r.Spec.RelationResource.Ingress; ingress != nil {
+		if err := r.validateIngress(ingress, old); err != nil {
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	}
+
+	// 检查Gateway API HTTPRoute配置
+	if route := r.Spec.RelationResource.GatewayRoute; route != nil {
+		if err := r.validateGatewayRoute(route); err != nil {
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	}
+
+	// 检查CronJob的调度周期
+	if r.Spec.Category == CategoryCronJob {
+		if r.Spec.Batch == nil || r.Spec.Batch.Schedule == "" {
+			err := errors.New("spec.batch.schedule is required when spec.category is CronJob")
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+		if _, err := cron.ParseStandard(r.Spec.Batch.Schedule); err != nil {
+			err = fmt.Errorf("spec.batch.schedule %q is not a valid cron expression: %v", r.Spec.Batch.Schedule, err)
+			unitlog.Error(err, "validate failed", "name", r.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+// Job类型的Unit，影响pod模板的字段创建后不能修改，需要重新执行时删除并重新创建Unit
+func (r *Unit) validateJobUpdate(old *Unit) error {
+	var oldBackoffLimit, newBackoffLimit *int32
+	if old.Spec.Batch != nil {
+		oldBackoffLimit = old.Spec.Batch.BackoffLimit
+	}
+	if r.Spec.Batch != nil {
+		newBackoffLimit = r.Spec.Batch.BackoffLimit
+	}
+
+	var changed []string
+	if !reflect.DeepEqual(old.Spec.Template, r.Spec.Template) {
+		changed = append(changed, "spec.template")
+	}
+	if !reflect.DeepEqual(oldBackoffLimit, newBackoffLimit) {
+		changed = append(changed, "spec.batch.backoffLimit")
+	}
+	if !reflect.DeepEqual(old.Spec.InjectEnv, r.Spec.InjectEnv) {
+		changed = append(changed, "spec.injectEnv")
+	}
+	if !reflect.DeepEqual(old.Spec.RelationResource.Volumes, r.Spec.RelationResource.Volumes) {
+		changed = append(changed, "spec.relationResource.volumes")
+	}
+	if len(changed) > 0 {
+		return fmt.Errorf("%s can not be changed when spec.category is Job, the Job is immutable once created, "+
+			"delete and recreate the Unit to run it again", strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// DaemonSet/Job/CronJob 不使用replicas
+func (r *Unit) replicasIgnored() bool {
+	return r.Spec.Category == CategoryDaemonSet || r.isBatch()
+}
+
+func (r *Unit) isBatch() bool {
+	return r.Spec.Category == CategoryJob || r.Spec.Category == CategoryCronJob
+}
+
+// 检查Service的类型、clusterIP和健康检查配置：
+// StatefulSet的主Service是headless service，只能是ClusterIP且clusterIP为None；
+// externalTrafficPolicy只对NodePort/LoadBalancer有效，loadBalancerSourceRanges只对LoadBalancer有效
+func (r *Unit) validateService(field string, service *OwnService) error {
+	serviceType := service.Type
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+	if service.headless(r) && serviceType != corev1.ServiceTypeClusterIP {
+		return fmt.Errorf("%s.type %s is not allowed when spec.category is StatefulSet, "+
+			"the service is used as headless service, use spec.relationResource.services instead", field, serviceType)
+	}
+	if service.headless(r) && service.ClusterIP != "" && service.ClusterIP != corev1.ClusterIPNone {
+		return fmt.Errorf("%s.clusterIP must be None when spec.category is StatefulSet", field)
+	}
+	if service.ExternalTrafficPolicy != "" && serviceType == corev1.ServiceTypeClusterIP {
+		return fmt.Errorf("%s.externalTrafficPolicy is only allowed for NodePort or LoadBalancer service", field)
+	}
+	if len(service.LoadBalancerSourceRanges) > 0 && serviceType != corev1.ServiceTypeLoadBalancer {
+		return fmt.Errorf("%s.loadBalancerSourceRanges is only allowed for LoadBalancer service", field)
+	}
+	for _, check := range service.HealthChecks {
+		if err := service.validateHealthCheck(check); err != nil {
+			return fmt.Errorf("%s.healthChecks: %v", field, err)
+		}
+	}
+	return nil
+}
+
+// 健康检查的端口必须是Service中声明的TCP端口，httpGet/grpc只能配合对应的type使用
+func (ownService *OwnService) validateHealthCheck(check ServicePortHealthCheck) error {
+	var port *corev1.ServicePort
+	for i := range ownService.Ports {
+		servicePort := &ownService.Ports[i]
+		if (check.Port.Type == intstr.String && servicePort.Name == check.Port.StrVal) ||
+			(check.Port.Type == intstr.Int && servicePort.Port == check.Port.IntVal) {
+			port = servicePort
+			break
+		}
+	}
+	if port == nil {
+		return fmt.Errorf("port %s is not declared in ports", check.Port.String())
+	}
+	if port.Protocol != "" && port.Protocol != corev1.ProtocolTCP {
+		return fmt.Errorf("port %s is %s, only TCP port can be checked", check.Port.String(), port.Protocol)
+	}
+	if check.HTTPGet != nil && check.Type != HealthCheckHTTP {
+		return fmt.Errorf("httpGet of port %s is only allowed when type is HTTP", check.Port.String())
+	}
+	if check.GRPC != nil && check.Type != HealthCheckGRPC {
+		return fmt.Errorf("grpc of port %s is only allowed when type is GRPC", check.Port.String())
+	}
+	if check.HTTPGet != nil {
+		for _, status := range check.HTTPGet.ExpectedStatus {
+			if status < 100 || status > 599 {
+				return fmt.Errorf("expectedStatus %d of port %s is not a valid HTTP status code", status, check.Port.String())
+			}
+		}
+	}
+	return nil
+}
+
+// services中的Service必须指定name且不能重复，<Unit名称>-<name>需要是合法的Service名称
+func (r *Unit) validateNamedService(service *OwnService, serviceNames map[string]bool) error {
+	if service.Name == "" {
+		return errors.New("spec.relationResource.services[].name is required")
+	}
+	if serviceNames[service.Name] {
+		return fmt.Errorf("spec.relationResource.services[].name %s is duplicated", service.Name)
+	}
+	serviceNames[service.Name] = true
+	if errs := validation.IsDNS1035Label(service.ServiceName(r)); len(errs) > 0 {
+		return fmt.Errorf("spec.relationResource.services[].name %s is invalid, service name %s: %s",
+			service.Name, service.ServiceName(r), strings.Join(errs, ", "))
+	}
+	if len(service.Ports) == 0 {
+		return fmt.Errorf("spec.relationResource.services[].ports is required for service %s", service.Name)
+	}
+	return r.validateService(fmt.Sprintf("spec.relationResource.services[%s]", service.Name), service)
+}
+
+// volumes的name必须指定且不能与pod模板中已有的volume重名，mountPath必须指定，containers必须是pod模板中的容器
+func (r *Unit) validateVolumes() error {
+	volumeNames := make(map[string]bool)
+	for _, volume := range r.Spec.Template.Spec.Volumes {
+		volumeNames[volume.Name] = true
+	}
+	containerNames := make(map[string]bool)
+	for _, container := range r.Spec.Template.Spec.Containers {
+		containerNames[container.Name] = true
+	}
+
+	for _, volume := range r.Spec.RelationResource.Volumes {
+		if volume.Name == "" {
+			return errors.New("spec.relationResource.volumes[].name is required")
+		}
+		if volumeNames[volume.Name] {
+			return fmt.Errorf("spec.relationResource.volumes[].name %s is duplicated with other volumes", volume.Name)
+		}
+		volumeNames[volume.Name] = true
+		if errs := validation.IsDNS1123Label(volume.Name); len(errs) > 0 {
+			return fmt.Errorf("spec.relationResource.volumes[].name %s is invalid: %s", volume.Name, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsDNS1123Subdomain(volume.ClaimName(r)); len(errs) > 0 {
+			return fmt.Errorf("spec.relationResource.volumes[].name %s is invalid, PVC name %s: %s",
+				volume.Name, volume.ClaimName(r), strings.Join(errs, ", "))
+		}
+		if volume.MountPath == "" {
+			return fmt.Errorf("spec.relationResource.volumes[%s].mountPath is required", volume.Name)
+		}
+		for _, container := range volume.Containers {
+			if !containerNames[container] {
+				return fmt.Errorf("spec.relationResource.volumes[%s].containers %s is not found in spec.template", volume.Name, container)
+			}
+		}
+		if err := validateVolumeSnapshot(fmt.Sprintf("spec.relationResource.volumes[%s]", volume.Name), &volume); err != nil {
+			return err
+		}
+	}
+	if pvc := r.Spec.RelationResource.PVC; pvc != nil {
+		if err := validateVolumeSnapshot("spec.relationResource.pvcInfo", pvc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pod模板至少要有一个容器；injectEnv指定的容器必须存在，init容器只在initContainers开启时可以指定
+func (r *Unit) validateInjectEnv() error {
+	if len(r.Spec.Template.Spec.Containers) == 0 {
+		return errors.New("spec.template.spec.containers must have at least one container")
+	}
+	injectEnv := r.Spec.InjectEnv
+	if injectEnv == nil {
+		return nil
+	}
+
+	containerNames := make(map[string]bool)
+	for _, container := range r.Spec.Template.Spec.Containers {
+		containerNames[container.Name] = true
+	}
+	if injectEnv.InitContainers {
+		for _, container := range r.Spec.Template.Spec.InitContainers {
+			containerNames[container.Name] = true
+		}
+	}
+	for _, container := range injectEnv.Containers {
+		if !containerNames[container] {
+			return fmt.Errorf("spec.injectEnv.containers %s is not found in spec.template", container)
+		}
+	}
+
+	fields := make(map[InjectEnvField]bool)
+	for _, field := range injectEnv.Fields {
+		if _, ok := injectEnvNames[field]; !ok {
+			return fmt.Errorf("spec.injectEnv.fields %s is not supported", field)
+		}
+		if fields[field] {
+			return fmt.Errorf("spec.injectEnv.fields %s is duplicated", field)
+		}
+		fields[field] = true
+	}
+	if injectEnv.Prefix != "" {
+		if errs := validation.IsEnvVarName(injectEnv.Prefix + injectEnvNames[InjectEnvPodName]); len(errs) > 0 {
+			return fmt.Errorf("spec.injectEnv.prefix %s is invalid: %s", injectEnv.Prefix, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// snapshotClassName只用于retainPolicy为Snapshot以及定时备份
+func validateVolumeSnapshot(field string, volume *OwnPVC) error {
+	if volume.SnapshotClassName != nil && volume.RetainPolicy != PVCRetainPolicySnapshot && volume.Backup == nil {
+		return fmt.Errorf("%s.snapshotClassName is only allowed when retainPolicy is Snapshot or backup is specified", field)
+	}
+	if backup := volume.Backup; backup != nil {
+		if _, err := cron.ParseStandard(backup.Schedule); err != nil {
+			return fmt.Errorf("%s.backup.schedule %q is not a valid cron expression: %v", field, backup.Schedule, err)
+		}
+	}
+	return nil
+}
+
+// PVC创建后除resources.requests.storage外的spec都不可修改，storage只能调大；
+// StatefulSet的volumeClaimTemplates不可增删
+func (r *Unit) validateVolumesUpdate(oldUnit *Unit) error {
+	if oldUnit.Spec.RelationResource.PVC != nil && r.Spec.RelationResource.PVC != nil {
+		if err := validatePVCSpecUpdate("spec.relationResource.pvcInfo", oldUnit.Spec.RelationResource.PVC.Spec,
+			r.Spec.RelationResource.PVC.Spec); err != nil {
+			return err
+		}
+	}
+
+	oldVolumes := make(map[string]OwnPVC)
+	for _, volume := range oldUnit.Spec.RelationResource.Volumes {
+		oldVolumes[volume.Name] = volume
+	}
+	for _, volume := range r.Spec.RelationResource.Volumes {
+		oldVolume, ok := oldVolumes[volume.Name]
+		if !ok {
+			continue
+		}
+		field := fmt.Sprintf("spec.relationResource.volumes[%s]", volume.Name)
+		if err := validatePVCSpecUpdate(field, oldVolume.Spec, volume.Spec); err != nil {
+			return err
+		}
+	}
+
+	if oldUnit.Spec.Category == CategoryStatefulSet && r.Spec.Category == CategoryStatefulSet {
+		var oldNames, newNames []string
+		for _, volume := range oldUnit.Spec.RelationResource.Volumes {
+			oldNames = append(oldNames, volume.Name)
+		}
+		for _, volume := range r.Spec.RelationResource.Volumes {
+			newNames = append(newNames, volume.Name)
+		}
+		if !reflect.DeepEqual(oldNames, newNames) {
+			return errors.New("spec.relationResource.volumes can not be added or removed when spec.category is StatefulSet")
+		}
+	}
+	return nil
+}
+
+func validatePVCSpecUpdate(field string, oldSpec, newSpec corev1.PersistentVolumeClaimSpec) error {
+	oldStorage := oldSpec.Resources.Requests[corev1.ResourceStorage]
+	newStorage := newSpec.Resources.Requests[corev1.ResourceStorage]
+	if newStorage.Cmp(oldStorage) < 0 {
+		return fmt.Errorf("%s.spec.resources.requests.storage can not be decreased from %s to %s",
+			field, oldStorage.String(), newStorage.String())
+	}
+
+	// 忽略storage后其它字段必须保持不变
+	oldCopy, newCopy := oldSpec.DeepCopy(), newSpec.DeepCopy()
+	delete(oldCopy.Resources.Requests, corev1.ResourceStorage)
+	delete(newCopy.Resources.Requests, corev1.ResourceStorage)
+	if len(oldCopy.Resources.Requests) == 0 {
+		oldCopy.Resources.Requests = nil
+	}
+	if len(newCopy.Resources.Requests) == 0 {
+		newCopy.Resources.Requests = nil
+	}
+	if !reflect.DeepEqual(oldCopy, newCopy) {
+		return fmt.Errorf("%s.spec is immutable except resources.requests.storage", field)
+	}
+	return nil
+}
+
+// 检查Ingress的域名和后端Service配置。
+// 更新时只在相关配置变化时校验，避免校验规则收紧之前创建的Unit无法再修改其它字段
+func (r *Unit) validateIngress(ingress *OwnIngress, old *Unit) error {
+	ingressChanged := old == nil || !reflect.DeepEqual(old.Spec.RelationResource.Ingress, ingress)
+	servicesChanged := old == nil || !reflect.DeepEqual(old.Spec.RelationResource.Service, r.Spec.RelationResource.Service) ||
+		!reflect.DeepEqual(old.Spec.RelationResource.Services, r.Spec.RelationResource.Services)
+	if !ingressChanged && !servicesChanged {
+		return nil
+	}
+
+	hosts := ingress.allHosts()
+	if len(hosts) == 0 && ingressChanged {
+		return errors.New("spec.relationResource.ingressInfo requires at least one domain or host")
+	}
+
+	// 后端Service必须已声明，后端端口必须是该Service中声明的端口
+	for _, host := range hosts {
+		for _, path := range host.Paths {
+			service := backendService(r, path)
+			if service == nil {
+				if path.ServiceName != "" {
+					return fmt.Errorf("spec.relationResource.ingressInfo serviceName %s is not declared in spec.relationResource.services",
+						path.ServiceName)
+				}
+				return errors.New("spec.relationResource.serviceInfo is required when spec.relationResource.ingressInfo has paths without serviceName")
+			}
+
+			port := path.ServicePort
+			field := "spec.relationResource.serviceInfo.ports"
+			if path.ServiceName != "" {
+				field = fmt.Sprintf("spec.relationResource.services[%s].ports", path.ServiceName)
+			} else if port == nil {
+				port = ingress.ServicePort
+			}
+			if port != nil && !service.hasPort(*port) {
+				return fmt.Errorf("spec.relationResource.ingressInfo servicePort %s is not declared in %s", port.String(), field)
+			}
+		}
+	}
+	return nil
+}
+
+// 检查HTTPRoute的parentRefs和后端端口配置
+func (r *Unit) validateGatewayRoute(route *OwnGatewayRoute) error {
+	if len(route.ParentRefs) == 0 {
+		return errors.New("spec.relationResource.gatewayRoute.parentRefs requires at least one Gateway")
+	}
+
+	// 转发到Unit自身Service的后端，端口必须是OwnService中声明的端口
+	service := r.Spec.RelationResource.Service
+	for _, rule := range route.Rules {
+		for _, backend := range rule.BackendRefs {
+			if backend.Name != "" && backend.Name != r.Name {
+				continue
+			}
+			if service == nil {
+				return errors.New("spec.relationResource.serviceInfo is required when gatewayRoute backend is the Unit itself")
+			}
+			if backend.Port != nil && !service.hasPort(intstr.FromInt(int(*backend.Port))) {
+				return fmt.Errorf("spec.relationResource.gatewayRoute backend port %d is not declared in spec.relationResource.serviceInfo.ports",
+					*backend.Port)
+			}
+		}
+	}
+	if len(route.Rules) == 0 && service == nil {
+		return errors.New("spec.relationResource.serviceInfo is required when gatewayRoute backend is the Unit itself")
+	}
 	return nil
 }
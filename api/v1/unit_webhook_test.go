@@ -0,0 +1,37 @@
+package v1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"testing"
+)
+
+func TestValidateIngressUpdate(t *testing.T) {
+	newUnit := func(ingress *OwnIngress, port int32) *Unit {
+		unit := &Unit{}
+		unit.Spec.RelationResource.Service = &OwnService{Ports: []v1.ServicePort{{Name: "http", Port: port}}}
+		unit.Spec.RelationResource.Ingress = ingress
+		return unit
+	}
+	noHost := func() *OwnIngress { return &OwnIngress{} }
+	className := func(name string) *OwnIngress { return &OwnIngress{IngressClassName: &name} }
+	withHost := func() *OwnIngress { return &OwnIngress{Domains: []string{"unit.example.com"}} }
+
+	cases := []struct {
+		name    string
+		old     *Unit
+		new     *Unit
+		wantErr bool
+	}{
+		{name: "create without host", new: newUnit(noHost(), 80), wantErr: true},
+		{name: "update other fields of Unit without host", old: newUnit(noHost(), 80), new: newUnit(noHost(), 8080)},
+		{name: "update ingress to still have no host", old: newUnit(className("old"), 80),
+			new: newUnit(className("new"), 80), wantErr: true},
+		{name: "create with host", new: newUnit(withHost(), 80)},
+	}
+	for _, c := range cases {
+		err := c.new.validateIngress(c.new.Spec.RelationResource.Ingress, c.old)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: validateIngress error = %v, wantErr %v", c.name, err, c.wantErr)
+		}
+	}
+}
@@ -21,157 +21,918 @@ limitations under the License.
 package v1
 
 import (
+	"k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GRPCHealthCheck) DeepCopyInto(out *GRPCHealthCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GRPCHealthCheck.
+func (in *GRPCHealthCheck) DeepCopy() *GRPCHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(GRPCHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayBackendRef) DeepCopyInto(out *GatewayBackendRef) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayBackendRef.
+func (in *GatewayBackendRef) DeepCopy() *GatewayBackendRef {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayBackendRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayHeaderMatch) DeepCopyInto(out *GatewayHeaderMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayHeaderMatch.
+func (in *GatewayHeaderMatch) DeepCopy() *GatewayHeaderMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayHeaderMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayParentRef) DeepCopyInto(out *GatewayParentRef) {
+	*out = *in
+	if in.Group != nil {
+		in, out := &in.Group, &out.Group
+		*out = new(string)
+		**out = **in
+	}
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.SectionName != nil {
+		in, out := &in.SectionName, &out.SectionName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayParentRef.
+func (in *GatewayParentRef) DeepCopy() *GatewayParentRef {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayParentRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayPathMatch) DeepCopyInto(out *GatewayPathMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayPathMatch.
+func (in *GatewayPathMatch) DeepCopy() *GatewayPathMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayPathMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayRouteMatch) DeepCopyInto(out *GatewayRouteMatch) {
+	*out = *in
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(GatewayPathMatch)
+		**out = **in
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]GatewayHeaderMatch, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayRouteMatch.
+func (in *GatewayRouteMatch) DeepCopy() *GatewayRouteMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayRouteMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayRouteRule) DeepCopyInto(out *GatewayRouteRule) {
+	*out = *in
+	if in.Matches != nil {
+		in, out := &in.Matches, &out.Matches
+		*out = make([]GatewayRouteMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BackendRefs != nil {
+		in, out := &in.BackendRefs, &out.BackendRefs
+		*out = make([]GatewayBackendRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayRouteRule.
+func (in *GatewayRouteRule) DeepCopy() *GatewayRouteRule {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayRouteRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPHealthCheck) DeepCopyInto(out *HTTPHealthCheck) {
+	*out = *in
+	if in.ExpectedStatus != nil {
+		in, out := &in.ExpectedStatus, &out.ExpectedStatus
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHealthCheck.
+func (in *HTTPHealthCheck) DeepCopy() *HTTPHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressHost) DeepCopyInto(out *IngressHost) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]IngressPath, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressHost.
+func (in *IngressHost) DeepCopy() *IngressHost {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressPath) DeepCopyInto(out *IngressPath) {
+	*out = *in
+	if in.ServicePort != nil {
+		in, out := &in.ServicePort, &out.ServicePort
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressPath.
+func (in *IngressPath) DeepCopy() *IngressPath {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressPath)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressTLS) DeepCopyInto(out *IngressTLS) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressTLS.
+func (in *IngressTLS) DeepCopy() *IngressTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnCronJob) DeepCopyInto(out *OwnCronJob) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnCronJob.
+func (in *OwnCronJob) DeepCopy() *OwnCronJob {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnCronJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnDaemonSet) DeepCopyInto(out *OwnDaemonSet) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnDaemonSet.
+func (in *OwnDaemonSet) DeepCopy() *OwnDaemonSet {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnDaemonSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OwnDeployment) DeepCopyInto(out *OwnDeployment) {
 	*out = *in
 	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnDeployment.
-func (in *OwnDeployment) DeepCopy() *OwnDeployment {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnDeployment.
+func (in *OwnDeployment) DeepCopy() *OwnDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnGatewayRoute) DeepCopyInto(out *OwnGatewayRoute) {
+	*out = *in
+	if in.ParentRefs != nil {
+		in, out := &in.ParentRefs, &out.ParentRefs
+		*out = make([]GatewayParentRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]GatewayRouteRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnGatewayRoute.
+func (in *OwnGatewayRoute) DeepCopy() *OwnGatewayRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnGatewayRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnHPA) DeepCopyInto(out *OwnHPA) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnHPA.
+func (in *OwnHPA) DeepCopy() *OwnHPA {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnHPA)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnIngress) DeepCopyInto(out *OwnIngress) {
+	*out = *in
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]IngressHost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServicePort != nil {
+		in, out := &in.ServicePort, &out.ServicePort
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = make([]IngressTLS, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IngressClassName != nil {
+		in, out := &in.IngressClassName, &out.IngressClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnIngress.
+func (in *OwnIngress) DeepCopy() *OwnIngress {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnIngress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnJob) DeepCopyInto(out *OwnJob) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnJob.
+func (in *OwnJob) DeepCopy() *OwnJob {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnPDB) DeepCopyInto(out *OwnPDB) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnPDB.
+func (in *OwnPDB) DeepCopy() *OwnPDB {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnPDB)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnPVC) DeepCopyInto(out *OwnPVC) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SnapshotClassName != nil {
+		in, out := &in.SnapshotClassName, &out.SnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(PVCBackupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnPVC.
+func (in *OwnPVC) DeepCopy() *OwnPVC {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnPVC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnService) DeepCopyInto(out *OwnService) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]corev1.ServicePort, len(*in))
+		copy(*out, *in)
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HealthChecks != nil {
+		in, out := &in.HealthChecks, &out.HealthChecks
+		*out = make([]ServicePortHealthCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnService.
+func (in *OwnService) DeepCopy() *OwnService {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnStatefulSet) DeepCopyInto(out *OwnStatefulSet) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnStatefulSet.
+func (in *OwnStatefulSet) DeepCopy() *OwnStatefulSet {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnStatefulSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCBackupSpec) DeepCopyInto(out *PVCBackupSpec) {
+	*out = *in
+	if in.Retain != nil {
+		in, out := &in.Retain, &out.Retain
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCBackupSpec.
+func (in *PVCBackupSpec) DeepCopy() *PVCBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicePort) DeepCopyInto(out *ServicePort) {
+	*out = *in
+	out.TargetPort = in.TargetPort
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePort.
+func (in *ServicePort) DeepCopy() *ServicePort {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicePort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicePortHealthCheck) DeepCopyInto(out *ServicePortHealthCheck) {
+	*out = *in
+	out.Port = in.Port
+	if in.HTTPGet != nil {
+		in, out := &in.HTTPGet, &out.HTTPGet
+		*out = new(HTTPHealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		*out = new(GRPCHealthCheck)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePortHealthCheck.
+func (in *ServicePortHealthCheck) DeepCopy() *ServicePortHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicePortHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServicePortStatus) DeepCopyInto(out *ServicePortStatus) {
+	*out = *in
+	out.ServicePort = in.ServicePort
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePortStatus.
+func (in *ServicePortStatus) DeepCopy() *ServicePortStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServicePortStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Unit) DeepCopyInto(out *Unit) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Unit.
+func (in *Unit) DeepCopy() *Unit {
+	if in == nil {
+		return nil
+	}
+	out := new(Unit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Unit) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitAutoscalingSpec) DeepCopyInto(out *UnitAutoscalingSpec) {
+	*out = *in
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetMemoryUtilizationPercentage != nil {
+		in, out := &in.TargetMemoryUtilizationPercentage, &out.TargetMemoryUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]v2beta2.MetricSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitAutoscalingSpec.
+func (in *UnitAutoscalingSpec) DeepCopy() *UnitAutoscalingSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(OwnDeployment)
+	out := new(UnitAutoscalingSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OwnIngress) DeepCopyInto(out *OwnIngress) {
+func (in *UnitBatchSpec) DeepCopyInto(out *UnitBatchSpec) {
 	*out = *in
-	if in.Domains != nil {
-		in, out := &in.Domains, &out.Domains
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(bool)
+		**out = **in
+	}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SuccessfulJobsHistoryLimit != nil {
+		in, out := &in.SuccessfulJobsHistoryLimit, &out.SuccessfulJobsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedJobsHistoryLimit != nil {
+		in, out := &in.FailedJobsHistoryLimit, &out.FailedJobsHistoryLimit
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnIngress.
-func (in *OwnIngress) DeepCopy() *OwnIngress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitBatchSpec.
+func (in *UnitBatchSpec) DeepCopy() *UnitBatchSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(OwnIngress)
+	out := new(UnitBatchSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OwnPVC) DeepCopyInto(out *OwnPVC) {
+func (in *UnitCondition) DeepCopyInto(out *UnitCondition) {
 	*out = *in
-	in.Spec.DeepCopyInto(&out.Spec)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnPVC.
-func (in *OwnPVC) DeepCopy() *OwnPVC {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitCondition.
+func (in *UnitCondition) DeepCopy() *UnitCondition {
 	if in == nil {
 		return nil
 	}
-	out := new(OwnPVC)
+	out := new(UnitCondition)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OwnService) DeepCopyInto(out *OwnService) {
+func (in *UnitDrainSpec) DeepCopyInto(out *UnitDrainSpec) {
 	*out = *in
-	if in.Ports != nil {
-		in, out := &in.Ports, &out.Ports
-		*out = make([]corev1.ServicePort, len(*in))
-		copy(*out, *in)
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnService.
-func (in *OwnService) DeepCopy() *OwnService {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitDrainSpec.
+func (in *UnitDrainSpec) DeepCopy() *UnitDrainSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(OwnService)
+	out := new(UnitDrainSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OwnStatefulSet) DeepCopyInto(out *OwnStatefulSet) {
+func (in *UnitDrainStatus) DeepCopyInto(out *UnitDrainStatus) {
 	*out = *in
-	in.Spec.DeepCopyInto(&out.Spec)
+	in.PhaseStartTime.DeepCopyInto(&out.PhaseStartTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnStatefulSet.
-func (in *OwnStatefulSet) DeepCopy() *OwnStatefulSet {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitDrainStatus.
+func (in *UnitDrainStatus) DeepCopy() *UnitDrainStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(OwnStatefulSet)
+	out := new(UnitDrainStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServicePort) DeepCopyInto(out *ServicePort) {
+func (in *UnitEndpointPortStatus) DeepCopyInto(out *UnitEndpointPortStatus) {
 	*out = *in
-	out.TargetPort = in.TargetPort
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePort.
-func (in *ServicePort) DeepCopy() *ServicePort {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitEndpointPortStatus.
+func (in *UnitEndpointPortStatus) DeepCopy() *UnitEndpointPortStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ServicePort)
+	out := new(UnitEndpointPortStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServicePortStatus) DeepCopyInto(out *ServicePortStatus) {
+func (in *UnitGatewayRouteParentStatus) DeepCopyInto(out *UnitGatewayRouteParentStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]UnitCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitGatewayRouteParentStatus.
+func (in *UnitGatewayRouteParentStatus) DeepCopy() *UnitGatewayRouteParentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitGatewayRouteParentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitIngressPathStatus) DeepCopyInto(out *UnitIngressPathStatus) {
 	*out = *in
 	out.ServicePort = in.ServicePort
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServicePortStatus.
-func (in *ServicePortStatus) DeepCopy() *ServicePortStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitIngressPathStatus.
+func (in *UnitIngressPathStatus) DeepCopy() *UnitIngressPathStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ServicePortStatus)
+	out := new(UnitIngressPathStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Unit) DeepCopyInto(out *Unit) {
+func (in *UnitIngressRuleStatus) DeepCopyInto(out *UnitIngressRuleStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]UnitIngressPathStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Unit.
-func (in *Unit) DeepCopy() *Unit {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitIngressRuleStatus.
+func (in *UnitIngressRuleStatus) DeepCopy() *UnitIngressRuleStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Unit)
+	out := new(UnitIngressRuleStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Unit) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitInjectEnvSpec) DeepCopyInto(out *UnitInjectEnvSpec) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]InjectEnvField, len(*in))
+		copy(*out, *in)
 	}
-	return nil
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitInjectEnvSpec.
+func (in *UnitInjectEnvSpec) DeepCopy() *UnitInjectEnvSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitInjectEnvSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitInventoryEntry) DeepCopyInto(out *UnitInventoryEntry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitInventoryEntry.
+func (in *UnitInventoryEntry) DeepCopy() *UnitInventoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitInventoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitJobStatus) DeepCopyInto(out *UnitJobStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitJobStatus.
+func (in *UnitJobStatus) DeepCopy() *UnitJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitJobStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -206,9 +967,31 @@ func (in *UnitList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitMigrationStatus) DeepCopyInto(out *UnitMigrationStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitMigrationStatus.
+func (in *UnitMigrationStatus) DeepCopy() *UnitMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UnitRelationEndpointStatus) DeepCopyInto(out *UnitRelationEndpointStatus) {
 	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]UnitEndpointPortStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitRelationEndpointStatus.
@@ -221,6 +1004,21 @@ func (in *UnitRelationEndpointStatus) DeepCopy() *UnitRelationEndpointStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitRelationPDBStatus) DeepCopyInto(out *UnitRelationPDBStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitRelationPDBStatus.
+func (in *UnitRelationPDBStatus) DeepCopy() *UnitRelationPDBStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitRelationPDBStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UnitRelationResourceSpec) DeepCopyInto(out *UnitRelationResourceSpec) {
 	*out = *in
@@ -229,16 +1027,40 @@ func (in *UnitRelationResourceSpec) DeepCopyInto(out *UnitRelationResourceSpec)
 		*out = new(OwnService)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]OwnService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.PVC != nil {
 		in, out := &in.PVC, &out.PVC
 		*out = new(OwnPVC)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]OwnPVC, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Ingress != nil {
 		in, out := &in.Ingress, &out.Ingress
 		*out = new(OwnIngress)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PDB != nil {
+		in, out := &in.PDB, &out.PDB
+		*out = new(OwnPDB)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GatewayRoute != nil {
+		in, out := &in.GatewayRoute, &out.GatewayRoute
+		*out = new(OwnGatewayRoute)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitRelationResourceSpec.
@@ -255,9 +1077,16 @@ func (in *UnitRelationResourceSpec) DeepCopy() *UnitRelationResourceSpec {
 func (in *UnitRelationResourceStatus) DeepCopyInto(out *UnitRelationResourceStatus) {
 	*out = *in
 	in.Service.DeepCopyInto(&out.Service)
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]UnitRelationServiceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Ingress != nil {
 		in, out := &in.Ingress, &out.Ingress
-		*out = make([]v1beta1.IngressRule, len(*in))
+		*out = make([]UnitIngressRuleStatus, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -265,9 +1094,26 @@ func (in *UnitRelationResourceStatus) DeepCopyInto(out *UnitRelationResourceStat
 	if in.Endpoint != nil {
 		in, out := &in.Endpoint, &out.Endpoint
 		*out = make([]UnitRelationEndpointStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	in.PVC.DeepCopyInto(&out.PVC)
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]UnitVolumeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.PDB = in.PDB
+	if in.GatewayRoute != nil {
+		in, out := &in.GatewayRoute, &out.GatewayRoute
+		*out = make([]UnitGatewayRouteParentStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitRelationResourceStatus.
@@ -286,6 +1132,13 @@ func (in *UnitRelationServiceStatus) DeepCopyInto(out *UnitRelationServiceStatus
 	if in.Ports != nil {
 		in, out := &in.Ports, &out.Ports
 		*out = make([]ServicePortStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LoadBalancerIngress != nil {
+		in, out := &in.LoadBalancerIngress, &out.LoadBalancerIngress
+		*out = make([]corev1.LoadBalancerIngress, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -300,6 +1153,22 @@ func (in *UnitRelationServiceStatus) DeepCopy() *UnitRelationServiceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitRemovedResource) DeepCopyInto(out *UnitRemovedResource) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitRemovedResource.
+func (in *UnitRemovedResource) DeepCopy() *UnitRemovedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitRemovedResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UnitSpec) DeepCopyInto(out *UnitSpec) {
 	*out = *in
@@ -315,6 +1184,26 @@ func (in *UnitSpec) DeepCopyInto(out *UnitSpec) {
 	}
 	in.Template.DeepCopyInto(&out.Template)
 	in.RelationResource.DeepCopyInto(&out.RelationResource)
+	if in.Batch != nil {
+		in, out := &in.Batch, &out.Batch
+		*out = new(UnitBatchSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(UnitAutoscalingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(UnitDrainSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InjectEnv != nil {
+		in, out := &in.InjectEnv, &out.InjectEnv
+		*out = new(UnitInjectEnvSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitSpec.
@@ -336,9 +1225,60 @@ func (in *UnitStatus) DeepCopyInto(out *UnitStatus) {
 		**out = **in
 	}
 	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]UnitCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.BaseDeployment.DeepCopyInto(&out.BaseDeployment)
 	in.BaseStatefulSet.DeepCopyInto(&out.BaseStatefulSet)
+	in.BaseDaemonSet.DeepCopyInto(&out.BaseDaemonSet)
+	in.BaseJob.DeepCopyInto(&out.BaseJob)
+	in.BaseCronJob.DeepCopyInto(&out.BaseCronJob)
 	in.RelationResourceStatus.DeepCopyInto(&out.RelationResourceStatus)
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(v2beta2.HorizontalPodAutoscalerStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.JobHistory != nil {
+		in, out := &in.JobHistory, &out.JobHistory
+		*out = make([]UnitJobStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(UnitMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backups != nil {
+		in, out := &in.Backups, &out.Backups
+		*out = make([]UnitVolumeSnapshotStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RemovedResources != nil {
+		in, out := &in.RemovedResources, &out.RemovedResources
+		*out = make([]UnitRemovedResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = make([]UnitInventoryEntry, len(*in))
+		copy(*out, *in)
+	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(UnitDrainStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitStatus.
@@ -350,3 +1290,58 @@ func (in *UnitStatus) DeepCopy() *UnitStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitVolumeSnapshotStatus) DeepCopyInto(out *UnitVolumeSnapshotStatus) {
+	*out = *in
+	in.CreationTime.DeepCopyInto(&out.CreationTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitVolumeSnapshotStatus.
+func (in *UnitVolumeSnapshotStatus) DeepCopy() *UnitVolumeSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitVolumeSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitVolumeStatus) DeepCopyInto(out *UnitVolumeStatus) {
+	*out = *in
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequestedStorage != nil {
+		in, out := &in.RequestedStorage, &out.RequestedStorage
+		*out = new(resource.Quantity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]corev1.PersistentVolumeClaimCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitVolumeStatus.
+func (in *UnitVolumeStatus) DeepCopy() *UnitVolumeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitVolumeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,197 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/net/http2"
+	"io/ioutil"
+	"net"
+	"net/http"
+)
+
+// 对 host:port 做一次健康检查，不健康时返回error说明原因
+type Checker interface {
+	Check(ctx context.Context, address string) error
+}
+
+// 建立TCP连接即视为健康
+type TCPChecker struct{}
+
+func (c *TCPChecker) Check(ctx context.Context, address string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// 发送HTTP GET请求，返回码在ExpectedStatus中视为健康，未指定时与kubelet的httpGet探针一致，200~399视为健康
+type HTTPChecker struct {
+	Scheme         string
+	Path           string
+	ExpectedStatus []int
+}
+
+func (c *HTTPChecker) Check(ctx context.Context, address string) error {
+	scheme := "http"
+	if c.Scheme == "HTTPS" || c.Scheme == "https" {
+		scheme = "https"
+	}
+	url := fmt.Sprintf("%s://%s%s", scheme, address, c.Path)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	transport := &http.Transport{
+		// 与kubelet一致，不校验后端证书
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		DisableKeepAlives: true,
+	}
+	defer transport.CloseIdleConnections()
+	resp, err := (&http.Client{Transport: transport}).Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if len(c.ExpectedStatus) == 0 {
+		if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusBadRequest {
+			return nil
+		}
+		return fmt.Errorf("GET %s returned unexpected status %d", url, resp.StatusCode)
+	}
+	for _, status := range c.ExpectedStatus {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("GET %s returned status %d, expected %v", url, resp.StatusCode, c.ExpectedStatus)
+}
+
+// 调用标准的gRPC健康检查服务 grpc.health.v1.Health/Check，返回SERVING视为健康。
+// 只需要这一个unary调用，直接基于h2c发送gRPC请求，不引入完整的gRPC依赖
+type GRPCChecker struct {
+	// 为空时检查整个server的状态
+	Service string
+}
+
+// grpc.health.v1.HealthCheckResponse.ServingStatus
+var grpcServingStatus = map[uint64]string{0: "UNKNOWN", 1: "SERVING", 2: "NOT_SERVING", 3: "SERVICE_UNKNOWN"}
+
+func (c *GRPCChecker) Check(ctx context.Context, address string) error {
+	transport := &http2.Transport{
+		AllowHTTP: true,
+		// 明文的h2c连接
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	url := fmt.Sprintf("http://%s/grpc.health.v1.Health/Check", address)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(encodeHealthCheckRequest(c.Service)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	resp, err := transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// 出错时server可能只返回header(Trailers-Only)
+	grpcStatus := resp.Trailer.Get("Grpc-Status")
+	grpcMessage := resp.Trailer.Get("Grpc-Message")
+	if grpcStatus == "" {
+		grpcStatus = resp.Header.Get("Grpc-Status")
+		grpcMessage = resp.Header.Get("Grpc-Message")
+	}
+	if grpcStatus != "0" {
+		return fmt.Errorf("grpc health check failed, grpc-status: %q, grpc-message: %q", grpcStatus, grpcMessage)
+	}
+
+	status, err := decodeHealthCheckResponse(body)
+	if err != nil {
+		return err
+	}
+	if status != 1 {
+		return fmt.Errorf("grpc health status is %s", grpcServingStatus[status])
+	}
+	return nil
+}
+
+// gRPC消息帧：1字节压缩标识 + 4字节大端长度 + protobuf编码的HealthCheckRequest{service = 1}
+func encodeHealthCheckRequest(service string) []byte {
+	var message []byte
+	if service != "" {
+		message = append(message, 0x0a)
+		message = appendUvarint(message, uint64(len(service)))
+		message = append(message, service...)
+	}
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// 解析HealthCheckResponse{status = 1}，status缺省时为UNKNOWN
+func decodeHealthCheckResponse(frame []byte) (uint64, error) {
+	if len(frame) < 5 {
+		return 0, errors.New("grpc health check response is too short")
+	}
+	if frame[0] != 0 {
+		return 0, errors.New("compressed grpc health check response is not supported")
+	}
+	length := binary.BigEndian.Uint32(frame[1:5])
+	if uint32(len(frame)-5) < length {
+		return 0, errors.New("grpc health check response is truncated")
+	}
+	message := frame[5 : 5+length]
+
+	var status uint64
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+		if n <= 0 {
+			return 0, errors.New("invalid grpc health check response")
+		}
+		message = message[n:]
+		switch tag & 0x7 {
+		case 0:
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return 0, errors.New("invalid grpc health check response")
+			}
+			message = message[n:]
+			if tag>>3 == 1 {
+				status = value
+			}
+		case 2:
+			size, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < size {
+				return 0, errors.New("invalid grpc health check response")
+			}
+			message = message[n+int(size):]
+		default:
+			return 0, fmt.Errorf("unexpected wire type %d in grpc health check response", tag&0x7)
+		}
+	}
+	return status, nil
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	tmp := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(tmp, v)
+	return append(buf, tmp[:n]...)
+}
@@ -0,0 +1,101 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeHealthCheckRequest(t *testing.T) {
+	cases := []struct {
+		name    string
+		service string
+		want    []byte
+	}{
+		{name: "whole server", service: "", want: []byte{0, 0, 0, 0, 0}},
+		{name: "named service", service: "svc", want: []byte{0, 0, 0, 0, 5, 0x0a, 3, 's', 'v', 'c'}},
+	}
+	for _, c := range cases {
+		if got := encodeHealthCheckRequest(c.service); !bytes.Equal(got, c.want) {
+			t.Errorf("%s: encodeHealthCheckRequest(%q) = %v, want %v", c.name, c.service, got, c.want)
+		}
+	}
+}
+
+func TestDecodeHealthCheckResponse(t *testing.T) {
+	cases := []struct {
+		name    string
+		frame   []byte
+		want    uint64
+		wantErr string
+	}{
+		{name: "serving", frame: []byte{0, 0, 0, 0, 2, 0x08, 1}, want: 1},
+		{name: "not serving", frame: []byte{0, 0, 0, 0, 2, 0x08, 2}, want: 2},
+		{name: "status omitted", frame: []byte{0, 0, 0, 0, 0}, want: 0},
+		{name: "unknown field skipped", frame: []byte{0, 0, 0, 0, 5, 0x12, 1, 'x', 0x08, 1}, want: 1},
+		{name: "short frame header", frame: []byte{0, 0, 0}, wantErr: "too short"},
+		{name: "short read", frame: []byte{0, 0, 0, 0, 5, 0x08, 1}, wantErr: "truncated"},
+		{name: "compressed", frame: []byte{1, 0, 0, 0, 2, 0x08, 1}, wantErr: "compressed"},
+		{name: "malformed varint", frame: []byte{0, 0, 0, 0, 1, 0x08}, wantErr: "invalid"},
+		{name: "malformed length", frame: []byte{0, 0, 0, 0, 3, 0x12, 5, 'x'}, wantErr: "invalid"},
+		{name: "unexpected wire type", frame: []byte{0, 0, 0, 0, 5, 0x0d, 1, 0, 0, 0}, wantErr: "wire type"},
+	}
+	for _, c := range cases {
+		got, err := decodeHealthCheckResponse(c.frame)
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%s: expected error containing %q, got %v", c.name, c.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: status = %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+// 模拟grpc.health.v1.Health/Check，返回指定的status
+func newGRPCHealthServer(status byte) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write([]byte{0, 0, 0, 0, 2, 0x08, status})
+		w.Header().Set("Grpc-Status", "0")
+	})
+	return httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+}
+
+func TestGRPCChecker(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  byte
+		wantErr string
+	}{
+		{name: "serving", status: 1},
+		{name: "not serving", status: 2, wantErr: "NOT_SERVING"},
+	}
+	for _, c := range cases {
+		server := newGRPCHealthServer(c.status)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := (&GRPCChecker{}).Check(ctx, server.Listener.Addr().String())
+		cancel()
+		server.Close()
+
+		if c.wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+		}
+		if c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)) {
+			t.Errorf("%s: expected error containing %q, got %v", c.name, c.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package healthcheck
+
+import (
+	"context"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// 一个需要周期性检查的端口
+type Target struct {
+	// 在所属owner内唯一，例如 <service>/<port>
+	Key     string
+	Address string
+	Checker Checker
+	Period  time.Duration
+	Timeout time.Duration
+}
+
+// 最近一次检查的结果
+type Result struct {
+	Healthy   bool
+	Message   string
+	Latency   time.Duration
+	CheckTime time.Time
+}
+
+type worker struct {
+	target Target
+	stop   chan struct{}
+}
+
+// Prober 在独立的goroutine中按各自的周期执行健康检查并缓存结果，调谐时只读取缓存，不会阻塞调谐循环。
+// 某个端口的健康状态发生变化时，通过notify通知owner重新调谐，以便将结果写入status
+type Prober struct {
+	notify func(owner types.NamespacedName)
+
+	mu      sync.Mutex
+	started bool
+	stop    <-chan struct{}
+	workers map[types.NamespacedName]map[string]*worker
+	results map[types.NamespacedName]map[string]Result
+}
+
+func NewProber(notify func(owner types.NamespacedName)) *Prober {
+	return &Prober{
+		notify:  notify,
+		workers: make(map[types.NamespacedName]map[string]*worker),
+		results: make(map[types.NamespacedName]map[string]Result),
+	}
+}
+
+// Start 实现manager.Runnable，manager退出时停止所有检查
+func (p *Prober) Start(stop <-chan struct{}) error {
+	p.mu.Lock()
+	p.started = true
+	p.stop = stop
+	p.mu.Unlock()
+
+	<-stop
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for owner := range p.workers {
+		p.removeLocked(owner)
+	}
+	return nil
+}
+
+// Sync 将owner的检查目标更新为targets：新增的开始检查，配置变化的重新开始，不再需要的停止并清除结果
+func (p *Prober) Sync(owner types.NamespacedName, targets []Target) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.started {
+		return
+	}
+
+	workers := p.workers[owner]
+	if workers == nil {
+		workers = make(map[string]*worker)
+		p.workers[owner] = workers
+	}
+	desired := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		desired[target.Key] = true
+		if w, ok := workers[target.Key]; ok {
+			if reflect.DeepEqual(w.target, target) {
+				continue
+			}
+			close(w.stop)
+			delete(p.results[owner], target.Key)
+		}
+		w := &worker{target: target, stop: make(chan struct{})}
+		workers[target.Key] = w
+		go p.run(owner, w)
+	}
+	for key, w := range workers {
+		if !desired[key] {
+			close(w.stop)
+			delete(workers, key)
+			delete(p.results[owner], key)
+		}
+	}
+	if len(workers) == 0 {
+		p.removeLocked(owner)
+	}
+}
+
+// Remove 停止owner的所有检查，owner被删除时调用
+func (p *Prober) Remove(owner types.NamespacedName) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removeLocked(owner)
+}
+
+func (p *Prober) removeLocked(owner types.NamespacedName) {
+	for _, w := range p.workers[owner] {
+		close(w.stop)
+	}
+	delete(p.workers, owner)
+	delete(p.results, owner)
+}
+
+// Result 返回缓存的最近一次检查结果，还没有完成过检查时返回false
+func (p *Prober) Result(owner types.NamespacedName, key string) (Result, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, ok := p.results[owner][key]
+	return result, ok
+}
+
+func (p *Prober) run(owner types.NamespacedName, w *worker) {
+	ticker := time.NewTicker(w.target.Period)
+	defer ticker.Stop()
+	for {
+		p.probe(owner, w)
+		select {
+		case <-w.stop:
+			return
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Prober) probe(owner types.NamespacedName, w *worker) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.target.Timeout)
+	start := time.Now()
+	err := w.target.Checker.Check(ctx, w.target.Address)
+	cancel()
+
+	result := Result{Healthy: err == nil, Latency: time.Since(start), CheckTime: start}
+	if err != nil {
+		result.Message = err.Error()
+	}
+
+	p.mu.Lock()
+	select {
+	case <-w.stop:
+		// 检查期间目标已被移除或替换，丢弃结果
+		p.mu.Unlock()
+		return
+	default:
+	}
+	last, checked := p.results[owner][w.target.Key]
+	if p.results[owner] == nil {
+		p.results[owner] = make(map[string]Result)
+	}
+	p.results[owner][w.target.Key] = result
+	p.mu.Unlock()
+
+	if !checked || last.Healthy != result.Healthy {
+		p.notify(owner)
+	}
+}
@@ -0,0 +1,144 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 返回预设结果的Checker，不做任何网络请求
+type fakeChecker struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (c *fakeChecker) Check(ctx context.Context, address string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *fakeChecker) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+type notifyCounter struct {
+	mu    sync.Mutex
+	count map[types.NamespacedName]int
+}
+
+func (n *notifyCounter) notify(owner types.NamespacedName) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.count[owner]++
+}
+
+func (n *notifyCounter) get(owner types.NamespacedName) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.count[owner]
+}
+
+func waitFor(t *testing.T, desc string, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", desc)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func startProber(t *testing.T) (*Prober, *notifyCounter, func()) {
+	counter := &notifyCounter{count: map[types.NamespacedName]int{}}
+	prober := NewProber(counter.notify)
+	stop := make(chan struct{})
+	go prober.Start(stop)
+	waitFor(t, "prober started", func() bool {
+		prober.mu.Lock()
+		defer prober.mu.Unlock()
+		return prober.started
+	})
+	return prober, counter, func() { close(stop) }
+}
+
+func TestProberSyncBeforeStart(t *testing.T) {
+	prober := NewProber(func(types.NamespacedName) {})
+	owner := types.NamespacedName{Namespace: "default", Name: "unit"}
+	prober.Sync(owner, []Target{{Key: "svc/80", Checker: &fakeChecker{}, Period: time.Millisecond, Timeout: time.Second}})
+	if len(prober.workers) != 0 {
+		t.Fatalf("expected no workers before the prober is started, got %d", len(prober.workers))
+	}
+}
+
+func TestProberResult(t *testing.T) {
+	prober, counter, stop := startProber(t)
+	defer stop()
+
+	owner := types.NamespacedName{Namespace: "default", Name: "unit"}
+	checker := &fakeChecker{}
+	target := Target{Key: "svc/80", Address: "10.0.0.1:80", Checker: checker, Period: 10 * time.Millisecond, Timeout: time.Second}
+	prober.Sync(owner, []Target{target})
+
+	// 第一次检查完成后通知owner
+	waitFor(t, "first result", func() bool {
+		result, ok := prober.Result(owner, target.Key)
+		return ok && result.Healthy
+	})
+	waitFor(t, "first notify", func() bool { return counter.get(owner) == 1 })
+
+	// 结果不变时不再通知
+	time.Sleep(50 * time.Millisecond)
+	if got := counter.get(owner); got != 1 {
+		t.Fatalf("expected 1 notify while healthy, got %d", got)
+	}
+
+	// 变为不健康时通知，并带上失败原因
+	checker.setErr(errors.New("connection refused"))
+	waitFor(t, "unhealthy result", func() bool {
+		result, ok := prober.Result(owner, target.Key)
+		return ok && !result.Healthy && result.Message == "connection refused"
+	})
+	waitFor(t, "unhealthy notify", func() bool { return counter.get(owner) == 2 })
+
+	// 目标不再需要时停止检查并清除结果
+	prober.Sync(owner, nil)
+	if _, ok := prober.Result(owner, target.Key); ok {
+		t.Fatalf("expected result to be removed after target is removed")
+	}
+	prober.mu.Lock()
+	workers := len(prober.workers)
+	prober.mu.Unlock()
+	if workers != 0 {
+		t.Fatalf("expected no workers after all targets are removed, got %d", workers)
+	}
+}
+
+func TestProberRemove(t *testing.T) {
+	prober, _, stop := startProber(t)
+	defer stop()
+
+	owner := types.NamespacedName{Namespace: "default", Name: "unit"}
+	other := types.NamespacedName{Namespace: "default", Name: "other"}
+	for _, o := range []types.NamespacedName{owner, other} {
+		prober.Sync(o, []Target{{Key: "svc/80", Checker: &fakeChecker{}, Period: 10 * time.Millisecond, Timeout: time.Second}})
+	}
+	waitFor(t, "results of both owners", func() bool {
+		_, ok1 := prober.Result(owner, "svc/80")
+		_, ok2 := prober.Result(other, "svc/80")
+		return ok1 && ok2
+	})
+
+	prober.Remove(owner)
+	if _, ok := prober.Result(owner, "svc/80"); ok {
+		t.Fatalf("expected result of removed owner to be cleared")
+	}
+	if _, ok := prober.Result(other, "svc/80"); !ok {
+		t.Fatalf("expected result of other owner to be kept")
+	}
+}
@@ -54,7 +54,11 @@ var _ = BeforeSuite(func(done Done) {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths: []string{filepath.Join("..", "config", "crd", "bases")},
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			// Gateway API 等Unit依赖的第三方CRD
+			filepath.Join("..", "config", "crd", "test"),
+		},
 	}
 
 	var err error
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"time"
+
+	customv1 "Unit/api/v1"
+)
+
+// PVC定时备份：到期时为PVC创建VolumeSnapshot，按retain数量清理旧的备份，并将备份列表写入Unit.status。
+// 返回值requeueAfter为距离下一次需要检查的时间，0表示不需要定时重新调谐
+func (r *UnitReconciler) backupVolumes(instance *customv1.Unit) (requeueAfter time.Duration, err error) {
+	var volumes []*customv1.OwnPVC
+	for _, volume := range unitVolumes(instance) {
+		if volume.Backup != nil {
+			volumes = append(volumes, volume)
+		}
+	}
+	if len(volumes) == 0 {
+		instance.Status.Backups = nil
+		return 0, nil
+	}
+	if r.VolumeSnapshotAPIVersion == "" {
+		return 0, fmt.Errorf("VolumeSnapshot API is not served by the cluster, can not backup Unit %s/%s",
+			instance.Namespace, instance.Name)
+	}
+
+	// Unit已有的定时备份，按来源PVC分组
+	snapshotList := &unstructured.UnstructuredList{}
+	snapshotList.SetAPIVersion(r.VolumeSnapshotAPIVersion)
+	snapshotList.SetKind(customv1.VolumeSnapshotKind + "List")
+	err = r.List(context.TODO(), snapshotList, client.InNamespace(instance.Namespace),
+		client.MatchingLabels{customv1.UnitNameLabel: instance.Name, customv1.BackupLabel: "scheduled"})
+	if err != nil {
+		msg := fmt.Sprintf("list VolumeSnapshot of Unit %s/%s error", instance.Namespace, instance.Name)
+		r.Log.Error(err, msg)
+		return 0, err
+	}
+	snapshots := make(map[string][]unstructured.Unstructured)
+	for _, snapshot := range snapshotList.Items {
+		claimName := customv1.VolumeSnapshotSource(&snapshot)
+		snapshots[claimName] = append(snapshots[claimName], snapshot)
+	}
+
+	now := time.Now()
+	var backupsStatus []customv1.UnitVolumeSnapshotStatus
+	for _, volume := range volumes {
+		// schedule在admission validating webhook里已校验
+		schedule, err := cron.ParseStandard(volume.Backup.Schedule)
+		if err != nil {
+			return 0, err
+		}
+		claims, err := volume.ListClaims(instance, r.Client)
+		if err != nil {
+			return 0, err
+		}
+
+		for i := range claims {
+			claim := &claims[i]
+			existing := snapshots[claim.Name]
+			// 从新到旧排列
+			sort.Slice(existing, func(i, j int) bool {
+				ti, tj := existing[i].GetCreationTimestamp(), existing[j].GetCreationTimestamp()
+				return tj.Before(&ti)
+			})
+
+			if !volume.Backup.Suspend {
+				last := claim.CreationTimestamp.Time
+				if len(existing) > 0 {
+					last = existing[0].GetCreationTimestamp().Time
+				}
+				next := schedule.Next(last)
+				if !next.After(now) {
+					// 错过了多个周期时只补做一次备份
+					snapshot, err := r.createBackup(instance, claim, volume.SnapshotClassName, now)
+					if err != nil {
+						return 0, err
+					}
+					existing = append([]unstructured.Unstructured{*snapshot}, existing...)
+					next = schedule.Next(now)
+				}
+				requeueAfter = minRequeue(requeueAfter, next.Sub(now))
+			}
+
+			retain := int(customv1.DefaultBackupRetain)
+			if volume.Backup.Retain != nil {
+				retain = int(*volume.Backup.Retain)
+			}
+			if len(existing) > retain {
+				for j := range existing[retain:] {
+					if err := r.pruneBackup(instance, &existing[retain+j]); err != nil {
+						return 0, err
+					}
+				}
+				existing = existing[:retain]
+			}
+
+			for j := range existing {
+				backupStatus := newBackupStatus(&existing[j], volume.Name)
+				if !backupStatus.ReadyToUse && backupStatus.Error == "" {
+					// 快照的状态变化不会触发Unit的调谐，就绪前定时刷新
+					requeueAfter = minRequeue(requeueAfter, snapshotRequeueInterval)
+				}
+				backupsStatus = append(backupsStatus, backupStatus)
+			}
+		}
+	}
+
+	sort.SliceStable(backupsStatus, func(i, j int) bool {
+		return backupsStatus[j].CreationTime.Before(&backupsStatus[i].CreationTime)
+	})
+	instance.Status.Backups = backupsStatus
+	return requeueAfter, nil
+}
+
+func (r *UnitReconciler) createBackup(instance *customv1.Unit, claim *corev1.PersistentVolumeClaim,
+	snapshotClassName *string, now time.Time) (*unstructured.Unstructured, error) {
+
+	name := fmt.Sprintf("%s-%d", claim.Name, now.Unix())
+	labels := map[string]string{customv1.UnitNameLabel: instance.Name, customv1.BackupLabel: "scheduled"}
+	snapshot := customv1.NewVolumeSnapshot(r.VolumeSnapshotAPIVersion, claim.Namespace, name, claim.Name, snapshotClassName, labels)
+	if err := r.Create(context.TODO(), snapshot); err != nil {
+		r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "Failed to create VolumeSnapshot %s/%s, reason: %s, error: %v",
+			claim.Namespace, name, errors.ReasonForError(err), err)
+		return nil, err
+	}
+	msg := fmt.Sprintf("Backup PVC %s/%s to VolumeSnapshot %s", claim.Namespace, claim.Name, name)
+	r.Log.Info(msg)
+	r.recordEvent(instance, corev1.EventTypeNormal, customv1.EventReasonCreated, "%s VolumeSnapshot %s/%s of PVC %s",
+		customv1.EventReasonCreated, claim.Namespace, name, claim.Name)
+	return snapshot, nil
+}
+
+// 删除超出保留数量的备份
+func (r *UnitReconciler) pruneBackup(instance *customv1.Unit, snapshot *unstructured.Unstructured) error {
+	if err := r.Delete(context.TODO(), snapshot); err != nil && !errors.IsNotFound(err) {
+		r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "Failed to delete VolumeSnapshot %s/%s, reason: %s, error: %v",
+			snapshot.GetNamespace(), snapshot.GetName(), errors.ReasonForError(err), err)
+		return err
+	}
+	r.recordEvent(instance, corev1.EventTypeNormal, customv1.EventReasonDeleted, "%s VolumeSnapshot %s/%s, exceeded backup retain",
+		customv1.EventReasonDeleted, snapshot.GetNamespace(), snapshot.GetName())
+	return nil
+}
+
+func newBackupStatus(snapshot *unstructured.Unstructured, volumeName string) customv1.UnitVolumeSnapshotStatus {
+	ready, message := customv1.VolumeSnapshotReady(snapshot)
+	restoreSize, _, _ := unstructured.NestedString(snapshot.Object, "status", "restoreSize")
+	return customv1.UnitVolumeSnapshotStatus{
+		Name:         snapshot.GetName(),
+		ClaimName:    customv1.VolumeSnapshotSource(snapshot),
+		VolumeName:   volumeName,
+		CreationTime: snapshot.GetCreationTimestamp(),
+		ReadyToUse:   ready,
+		RestoreSize:  restoreSize,
+		Error:        message,
+	}
+}
+
+// 取两个重新调谐间隔中较小的，0表示不需要重新调谐
+func minRequeue(a, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	customv1 "Unit/api/v1"
+)
+
+// status.removedResources最多保留的记录数
+const maxRemovedResources = 10
+
+// 可能从spec中移除的关联资源，key为资源的Kind。
+// 工作负载由category迁移处理；PVC涉及数据，从spec中移除后不会自动删除，随Unit删除时按retainPolicy处理
+func (r *UnitReconciler) relationResourceLists() map[string]runtime.Object {
+	ingressList := &unstructured.UnstructuredList{}
+	ingressList.SetAPIVersion(r.IngressAPIVersion)
+	ingressList.SetKind("IngressList")
+
+	lists := map[string]runtime.Object{
+		"Service":                 &corev1.ServiceList{},
+		"Ingress":                 ingressList,
+		"HorizontalPodAutoscaler": &autoscalingv2beta2.HorizontalPodAutoscalerList{},
+		"PodDisruptionBudget":     &policyv1beta1.PodDisruptionBudgetList{},
+	}
+	if r.gatewayRouteServed {
+		routeList := &unstructured.UnstructuredList{}
+		routeList.SetAPIVersion(customv1.GatewayAPIVersion)
+		routeList.SetKind(customv1.HTTPRouteKind + "List")
+		lists[customv1.HTTPRouteKind] = routeList
+	}
+	return lists
+}
+
+// 列出controller为当前Unit的关联资源，生成inventory条目。
+// inventory引入之前创建的对象没有记录在inventory中，需要通过controller reference找回，之后由inventory跟踪
+func (r *UnitReconciler) controlledInventory(instance *customv1.Unit) ([]customv1.UnitInventoryEntry, error) {
+	var entries []customv1.UnitInventoryEntry
+	for kind, list := range r.relationResourceLists() {
+		if err := r.List(context.TODO(), list, client.InNamespace(instance.Namespace)); err != nil {
+			msg := fmt.Sprintf("list %s in namespace %s error", kind, instance.Namespace)
+			r.Log.Error(err, msg)
+			return nil, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				return nil, err
+			}
+			if !metav1.IsControlledBy(accessor, instance) {
+				continue
+			}
+			entry, err := newInventoryEntry(item, r.Scheme)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// 将清理记录合并到status.removedResources，最新的在前。
+// 保留的资源在去掉ownerReference之前可能重复出现，已有相同记录时沿用原来的时间，避免status无意义的变化
+func addRemovedResources(instance *customv1.Unit, removed []customv1.UnitRemovedResource) {
+	history := instance.Status.RemovedResources
+	for _, resource := range removed {
+		exists := false
+		for _, record := range history {
+			if record.Kind == resource.Kind && record.Name == resource.Name && record.Action == resource.Action &&
+				resource.Action == customv1.RemovedResourceKept {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+		if resource.Action == customv1.RemovedResourceKept {
+			msg := fmt.Sprintf("%s %s/%s is removed from Unit spec, kept by annotation %s and no longer owned by Unit",
+				resource.Kind, instance.Namespace, resource.Name, customv1.KeepResourceAnnotation)
+			resource.Message = msg
+		}
+		history = append([]customv1.UnitRemovedResource{resource}, history...)
+	}
+	if len(history) > maxRemovedResources {
+		history = history[:maxRemovedResources]
+	}
+	instance.Status.RemovedResources = history
+}
@@ -21,14 +21,28 @@ import (
 	"fmt"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	customv1 "Unit/api/v1"
+	"Unit/controllers/healthcheck"
 )
 
 type OwnResource interface {
@@ -42,24 +56,49 @@ type OwnResource interface {
 	UpdateOwnResourceStatus(instance *customv1.Unit, client client.Client, logger logr.Logger) (*customv1.Unit, error)
 
 	// 创建/更新 Unit对应的own build-in资源
-	ApplyOwnResource(instance *customv1.Unit, client client.Client, logger logr.Logger, scheme *runtime.Scheme) error
+	ApplyOwnResource(instance *customv1.Unit, client client.Client, logger logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) error
 }
 
 // UnitReconciler reconciles a Unit object
 type UnitReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// 集群提供的Ingress API版本，为空时在SetupWithManager中通过discovery探测
+	IngressAPIVersion string
+	// 集群提供的EndpointSlice API版本，在SetupWithManager中通过discovery探测，为空时使用Endpoints
+	EndpointSliceAPIVersion string
+	// 集群提供的VolumeSnapshot API版本，在SetupWithManager中通过discovery探测，为空时不支持快照
+	VolumeSnapshotAPIVersion string
+
+	// 集群是否安装了Gateway API，在SetupWithManager中通过discovery探测
+	gatewayRouteServed bool
+
+	// 在后台执行Service端口健康检查，在SetupWithManager中创建
+	healthProber *healthcheck.Prober
 }
 
 // +kubebuilder:rbac:groups=custom.my.crd.com,resources=units,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=custom.my.crd.com,resources=units/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=apps,resources=statefulSet,verbs=get;update;patch;delete
-// +kubebuilder:rbac:groups=apps,resources=deployment,verbs=get;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=service,verbs=get;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=endpoint,verbs=get
-// +kubebuilder:rbac:groups=core,resources=persistentVolumeClaimStatus,verbs=get;update;patch;delete
-// +kubebuilder:rbac:groups=extensions,resources=ingress,verbs=get;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=endpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=extensions,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *UnitReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	//_ = context.Background()
@@ -86,6 +125,7 @@ func (r *UnitReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		if errors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			r.stopPortHealth(req.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 	}
@@ -115,11 +155,18 @@ func (r *UnitReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		if containsString(instance.ObjectMeta.Finalizers, myFinalizerName) {
 
 			// 在删除owner resource之前，先执行自定义的预删除步骤，例如删除owner resource
-			if err := r.PreDelete(instance); err != nil {
+			waiting, err := r.PreDelete(instance)
+			if err != nil {
 				// if fail to delete the external dependency here, return with error
 				// so that it can be retried
 				return ctrl.Result{}, err
 			}
+			if waiting {
+				// 预删除步骤还未完成，例如还在优雅下线或PVC的快照还未就绪，稍后重新检查
+				return ctrl.Result{RequeueAfter: preDeleteRequeueInterval}, nil
+			}
+
+			r.stopPortHealth(req.NamespacedName)
 
 			// 移出掉自定义的Finalizers，这样当Finalizers为空时，gc就会正式开始了
 			instance.ObjectMeta.Finalizers = removeString(instance.ObjectMeta.Finalizers, myFinalizerName)
@@ -143,24 +190,81 @@ func (r *UnitReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 	// 3.2 判断各own resource 是否存在，不存在则创建，存在则判断spec是否有变化，有变化则更新
 	success := true
+	var ownResourceErrors []ownResourceError
+	var inventory []customv1.UnitInventoryEntry
+	if instance.Spec.RelationResource.GatewayRoute != nil && !r.gatewayRouteServed {
+		err := fmt.Errorf("%s is not served by the cluster, HTTPRoute is skipped", customv1.GatewayAPIVersion)
+		ownResourceErrors = append(ownResourceErrors, ownResourceError{Kind: customv1.HTTPRouteKind, Action: "Apply", Err: err})
+	}
 	for _, ownResource := range ownResources {
-		if err = ownResource.ApplyOwnResource(instance, r.Client, r.Log, r.Scheme); err != nil {
+		if err = ownResource.ApplyOwnResource(instance, r.Client, r.Log, r.Scheme, r.Recorder); err != nil {
 			success = false
+			ownResourceErrors = append(ownResourceErrors, ownResourceError{Kind: ownResourceKind(ownResource), Action: "Apply", Err: err})
+			continue
+		}
+		entry, entryErr := r.inventoryEntry(instance, ownResource)
+		if entryErr != nil {
+			msg := fmt.Sprintf("get %s of Unit %s/%s for inventory error", ownResourceKind(ownResource), instance.Namespace, instance.Name)
+			r.Log.Error(entryErr, msg)
+		} else if entry != nil {
+			inventory = append(inventory, *entry)
 		}
 	}
 
 	// 4. update Unit.status
-	// 4.1 更新实例Unit.Status 字段
+	// 4.1 更新实例Unit.Status 字段，services和volumes的状态按spec重新生成
 	updateInstance := instance.DeepCopy()
+	updateInstance.Status.RelationResourceStatus.Services = nil
+	updateInstance.Status.RelationResourceStatus.Volumes = nil
 	for _, ownResource := range ownResources {
 		updateInstance, err = ownResource.UpdateOwnResourceStatus(updateInstance, r.Client, r.Log)
 		if err != nil {
 			//fmt.Println("update Unit ownresource status error:", err)
 			success = false
+			ownResourceErrors = append(ownResourceErrors, ownResourceError{Kind: ownResourceKind(ownResource), Action: "UpdateStatus", Err: err})
 		}
 	}
+	r.syncPortHealth(updateInstance, ownResources)
+	r.updateClusterIPMismatch(updateInstance, ownResources)
+
+	// 4.2 spec.category 变更时，等待新的工作负载就绪后再清理旧的工作负载
+	migration, migrating, migrateErr := r.migrateWorkload(updateInstance)
+	if migrateErr != nil {
+		msg := fmt.Sprintf("migrate Unit %s/%s workload error", instance.Namespace, instance.Name)
+		r.Log.Error(migrateErr, msg)
+		success = false
+		err = migrateErr
+		ownResourceErrors = append(ownResourceErrors, ownResourceError{Kind: instance.Spec.Category, Action: "Migrate", Err: migrateErr})
+	}
+	updateInstance.Status.Migration = migration
+	if migration != nil && migration.Phase == customv1.MigrationPhaseCompleted {
+		resetWorkloadStatus(updateInstance, migration.From)
+	}
 
-	// 4.2 apply update to apiServer if status changed
+	// 4.3 按上一次的inventory删除spec不再生成的对象，迁移未完成时暂不删除，旧工作负载删除后的调谐中再清理
+	inventory, removedResources, pruneErr := r.syncInventory(instance, ownResources, inventory, migrating || migrateErr != nil)
+	if pruneErr != nil {
+		success = false
+		err = pruneErr
+		ownResourceErrors = append(ownResourceErrors, ownResourceError{Kind: "Inventory", Action: "Prune", Err: pruneErr})
+	}
+	addRemovedResources(updateInstance, removedResources)
+	updateInstance.Status.Inventory = inventory
+
+	// 4.4 PVC定时备份
+	backupRequeueAfter, backupErr := r.backupVolumes(updateInstance)
+	if backupErr != nil {
+		msg := fmt.Sprintf("backup Unit %s/%s PVC error", instance.Namespace, instance.Name)
+		r.Log.Error(backupErr, msg)
+		success = false
+		err = backupErr
+		ownResourceErrors = append(ownResourceErrors, ownResourceError{Kind: customv1.VolumeSnapshotKind, Action: "Backup", Err: backupErr})
+	}
+
+	// 4.5 根据各own resource的调谐结果，计算Unit的conditions、observedGeneration和phase
+	updateUnitConditions(updateInstance, ownResources, ownResourceErrors)
+
+	// 4.6 apply update to apiServer if status changed
 	if updateInstance != nil && !reflect.DeepEqual(updateInstance.Status, instance.Status) {
 		if err := r.Status().Update(context.Background(), updateInstance); err != nil {
 			r.Log.Error(err, "unable to update Unit status")
@@ -172,27 +276,145 @@ func (r *UnitReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		msg := fmt.Sprintf("Reconciler Unit %s/%s failed ", instance.Namespace, instance.Name)
 		r.Log.Error(err, msg)
 		return ctrl.Result{}, err
+	} else if migrating {
+		// 迁移期间新工作负载的状态变化不会触发Unit的调谐，需要定时重新检查
+		msg := fmt.Sprintf("Unit %s/%s is migrating from %s to %s", instance.Namespace, instance.Name, migration.From, migration.To)
+		r.Log.Info(msg)
+		return ctrl.Result{RequeueAfter: migrationRequeueInterval}, nil
 	} else {
 		msg := fmt.Sprintf("Reconcile Unit %s/%s success", instance.Namespace, instance.Name)
 		r.Log.Info(msg)
-		return ctrl.Result{}, nil
+		// 开启定时备份时，到下一次备份的时间重新调谐
+		return ctrl.Result{RequeueAfter: backupRequeueAfter}, nil
 	}
 }
 
 func (r *UnitReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	// 启动时探测集群提供的Ingress API版本，新集群使用networking.k8s.io/v1，老集群使用v1beta1
+	if r.IngressAPIVersion == "" {
+		apiVersion, err := detectIngressAPIVersion(mgr.GetConfig())
+		if err != nil {
+			r.Log.Error(err, "detect Ingress API version error")
+			return err
+		}
+		r.IngressAPIVersion = apiVersion
+	}
+	r.Log.Info(fmt.Sprintf("use Ingress API version %s", r.IngressAPIVersion))
+
+	// Unit.status中的endpoint优先从EndpointSlice获取，老集群没有EndpointSlice时从Endpoints获取
+	if r.EndpointSliceAPIVersion == "" {
+		for _, apiVersion := range []string{customv1.EndpointSliceAPIVersionV1, customv1.EndpointSliceAPIVersionV1beta1} {
+			served, err := resourceServed(mgr.GetConfig(), apiVersion, "endpointslices")
+			if err != nil {
+				r.Log.Error(err, "detect EndpointSlice API version error")
+				return err
+			}
+			if served {
+				r.EndpointSliceAPIVersion = apiVersion
+				break
+			}
+		}
+	}
+	if r.EndpointSliceAPIVersion != "" {
+		r.Log.Info(fmt.Sprintf("use EndpointSlice API version %s for endpoint status", r.EndpointSliceAPIVersion))
+	}
+
+	// VolumeSnapshot的CRD和controller是可选安装的
+	if r.VolumeSnapshotAPIVersion == "" {
+		for _, apiVersion := range []string{customv1.VolumeSnapshotAPIVersionV1, customv1.VolumeSnapshotAPIVersionV1beta1} {
+			served, err := resourceServed(mgr.GetConfig(), apiVersion, "volumesnapshots")
+			if err != nil {
+				r.Log.Error(err, "detect VolumeSnapshot API version error")
+				return err
+			}
+			if served {
+				r.VolumeSnapshotAPIVersion = apiVersion
+				break
+			}
+		}
+	}
+	ingress := &unstructured.Unstructured{}
+	ingress.SetGroupVersionKind(schema.FromAPIVersionAndKind(r.IngressAPIVersion, "Ingress"))
+
+	// Service端口健康检查在后台执行，健康状态变化时通过channel触发Unit的调谐
+	healthEvents := make(chan event.GenericEvent, 100)
+	r.healthProber = healthcheck.NewProber(func(owner types.NamespacedName) {
+		unit := &customv1.Unit{ObjectMeta: metav1.ObjectMeta{Name: owner.Name, Namespace: owner.Namespace}}
+		healthEvents <- event.GenericEvent{Meta: unit, Object: unit}
+	})
+	if err := mgr.Add(r.healthProber); err != nil {
+		r.Log.Error(err, "add health prober to manager error")
+		return err
+	}
+
+	// Unit创建的own resource被修改或删除时，也触发Unit的调谐，以便立即纠正偏差并刷新Unit.status
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&customv1.Unit{}).
-		Complete(r)
+		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.StatefulSet{}).
+		Owns(&appsv1.DaemonSet{}).
+		Owns(&batchv1.Job{}).
+		Owns(&batchv1beta1.CronJob{}).
+		Owns(&autoscalingv2beta2.HorizontalPodAutoscaler{}).
+		Owns(&corev1.Service{}).
+		Owns(ingress).
+		Owns(&corev1.PersistentVolumeClaim{}).
+		Owns(&policyv1beta1.PodDisruptionBudget{}).
+		Watches(&source.Channel{Source: healthEvents}, &handler.EnqueueRequestForObject{}).
+		WithEventFilter(unitEventFilter)
+
+	// endpoint的变化需要同步到Unit.status，与UpdateOwnResourceStatus一致，集群提供EndpointSlice时watch EndpointSlice，否则watch Endpoints
+	if r.EndpointSliceAPIVersion != "" {
+		endpointSlice := &unstructured.Unstructured{}
+		endpointSlice.SetGroupVersionKind(schema.FromAPIVersionAndKind(r.EndpointSliceAPIVersion, "EndpointSlice"))
+		builder = builder.Watches(&source.Kind{Type: endpointSlice}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.endpointSliceToUnit),
+		})
+	} else {
+		builder = builder.Watches(&source.Kind{Type: &corev1.Endpoints{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.endpointsToUnit),
+		})
+	}
+
+	// Gateway API 的CRD是可选安装的，集群中存在时才watch HTTPRoute
+	served, err := resourceServed(mgr.GetConfig(), customv1.GatewayAPIVersion, "httproutes")
+	if err != nil {
+		r.Log.Error(err, "detect Gateway API error")
+		return err
+	}
+	r.gatewayRouteServed = served
+	if served {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(schema.FromAPIVersionAndKind(customv1.GatewayAPIVersion, customv1.HTTPRouteKind))
+		builder = builder.Owns(route)
+	} else {
+		r.Log.Info(fmt.Sprintf("%s is not served by the cluster, skip watching HTTPRoute", customv1.GatewayAPIVersion))
+	}
+	return builder.Complete(r)
 }
 
-// Unit pre delete logic
-func (r *UnitReconciler) PreDelete(instance *customv1.Unit) error {
+// Unit pre delete logic，返回waiting为true时表示预删除步骤还未完成，需要稍后重新调谐
+func (r *UnitReconciler) PreDelete(instance *customv1.Unit) (waiting bool, err error) {
 	// 特别说明，own resource加上了ControllerReference之后，owner resource gc删除前，会先自动删除它的所有
 	// own resources，因此绑定ControllerReference后无需再特别处理删除own resource。
 
-	// 这里留空出来，是为了如果有自定义的pre delete逻辑的需要，可在这里实现。
+	// deletionPolicy为Orphan时，去掉own resource上的ControllerReference，Unit回收后它们继续保留
+	if instance.Spec.DeletionPolicy == customv1.DeletionPolicyOrphan {
+		return false, r.orphanOwnResources(instance)
+	}
+
+	// 先摘掉流量、缩容，endpoints清空后再处理PVC
+	if waiting, err := r.drainWorkload(instance); err != nil || waiting {
+		return waiting, err
+	}
 
-	return nil
+	// PVC按retainPolicy保留或先做快照
+	return r.retainVolumes(instance)
+}
+
+// 在Unit上记录Event，Recorder为空时不记录
+func (r *UnitReconciler) recordEvent(instance *customv1.Unit, eventType, reason, messageFmt string, args ...interface{}) {
+	customv1.RecordEvent(instance, r.Recorder, eventType, reason, messageFmt, args...)
 }
 
 // Helper functions to check and remove string from a slice of strings.
@@ -219,40 +441,129 @@ func removeString(slice []string, s string) (result []string) {
 func (r *UnitReconciler) getOwnResources(instance *customv1.Unit) ([]OwnResource, error) {
 	var ownResources []OwnResource
 
-	// Deployment 和StatefulSet 二者只能存在其一。由于可以动态选择，所以ownDeployment或ownStatefulSet在后端生成，不由前端指定
-	if instance.Spec.Category == "Deployment" {
+	// 将spec.relationResource.volumes挂载到pod模板中，并按spec.injectEnv注入环境变量
+	template := instance.Spec.Template.DeepCopy()
+	customv1.InjectVolumes(instance, template)
+	customv1.InjectEnv(instance, template)
+
+	// Deployment、StatefulSet和DaemonSet 三者只能存在其一。由于可以动态选择，所以ownDeployment/ownStatefulSet/ownDaemonSet在后端生成，不由前端指定
+	switch instance.Spec.Category {
+	case customv1.CategoryDeployment:
 		ownDeployment := customv1.OwnDeployment{
 			Spec: appsv1.DeploymentSpec{
 				Replicas: instance.Spec.Replicas,
 				Selector: instance.Spec.Selector,
-				Template: instance.Spec.Template,
+				Template: *template,
 			},
 		}
 		ownDeployment.Spec.Template.Labels = instance.Spec.Selector.MatchLabels
 		ownResources = append(ownResources, &ownDeployment)
 
-	} else {
+	case customv1.CategoryDaemonSet:
+		// DaemonSet 每个节点运行一个pod，忽略Unit.Spec.Replicas
+		ownDaemonSet := &customv1.OwnDaemonSet{
+			Spec: appsv1.DaemonSetSpec{
+				Selector: instance.Spec.Selector,
+				Template: *template,
+			},
+		}
+		ownDaemonSet.Spec.Template.Labels = instance.Spec.Selector.MatchLabels
+		ownResources = append(ownResources, ownDaemonSet)
+
+	case customv1.CategoryJob:
+		// Job 的selector由job controller自动生成，这里不指定
+		ownJob := &customv1.OwnJob{
+			Spec: batchv1.JobSpec{
+				Template: *template,
+			},
+		}
+		ownJob.Spec.Template.Labels = instance.Spec.Selector.MatchLabels
+		if instance.Spec.Batch != nil {
+			ownJob.Spec.BackoffLimit = instance.Spec.Batch.BackoffLimit
+		}
+		ownResources = append(ownResources, ownJob)
+
+	case customv1.CategoryCronJob:
+		ownCronJob := &customv1.OwnCronJob{
+			Spec: batchv1beta1.CronJobSpec{
+				JobTemplate: batchv1beta1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{
+						Template: *template,
+					},
+				},
+			},
+		}
+		// CronJob创建的Job带上Unit的label，用于按label列出执行历史
+		ownCronJob.Spec.JobTemplate.Labels = instance.Spec.Selector.MatchLabels
+		ownCronJob.Spec.JobTemplate.Spec.Template.Labels = instance.Spec.Selector.MatchLabels
+		if batch := instance.Spec.Batch; batch != nil {
+			ownCronJob.Spec.Schedule = batch.Schedule
+			ownCronJob.Spec.ConcurrencyPolicy = batch.ConcurrencyPolicy
+			ownCronJob.Spec.Suspend = batch.Suspend
+			ownCronJob.Spec.SuccessfulJobsHistoryLimit = batch.SuccessfulJobsHistoryLimit
+			ownCronJob.Spec.FailedJobsHistoryLimit = batch.FailedJobsHistoryLimit
+			ownCronJob.Spec.JobTemplate.Spec.BackoffLimit = batch.BackoffLimit
+		}
+		ownResources = append(ownResources, ownCronJob)
+
+	default:
 		ownStatefulSet := &customv1.OwnStatefulSet{
 			Spec: appsv1.StatefulSetSpec{
 				Replicas:    instance.Spec.Replicas,
 				Selector:    instance.Spec.Selector,
-				Template:    instance.Spec.Template,
+				Template:    *template,
 				ServiceName: instance.Name,
+				// 每个副本一个PVC
+				VolumeClaimTemplates: customv1.VolumeClaimTemplates(instance),
 			},
 		}
 
 		ownResources = append(ownResources, ownStatefulSet)
 	}
 
-	// 将关联的资源(svc/ing/pvc)加入ownResources中
+	// 开启自动扩缩容时，创建以Unit自身为目标的HPA
+	if instance.Spec.Autoscaling != nil {
+		ownResources = append(ownResources, customv1.NewOwnHPA(instance))
+	}
+
+	// 将关联的资源(svc/ing/pvc/pdb)加入ownResources中
+	var ownService *customv1.OwnService
 	if instance.Spec.RelationResource.Service != nil {
-		ownResources = append(ownResources, instance.Spec.RelationResource.Service)
+		ownService = instance.Spec.RelationResource.Service.DeepCopy()
+	} else if instance.Spec.Category == customv1.CategoryStatefulSet {
+		// StatefulSet需要一个headless service作为governing service，未声明serviceInfo时也自动创建
+		ownService = &customv1.OwnService{}
+	}
+	if ownService != nil {
+		ownService.EndpointSliceAPIVersion = r.EndpointSliceAPIVersion
+		ownResources = append(ownResources, ownService)
+	}
+	for i := range instance.Spec.RelationResource.Services {
+		namedService := instance.Spec.RelationResource.Services[i].DeepCopy()
+		namedService.EndpointSliceAPIVersion = r.EndpointSliceAPIVersion
+		ownResources = append(ownResources, namedService)
 	}
 	if instance.Spec.RelationResource.Ingress != nil {
-		ownResources = append(ownResources, instance.Spec.RelationResource.Ingress)
+		ownIngress := instance.Spec.RelationResource.Ingress.DeepCopy()
+		ownIngress.APIVersion = r.IngressAPIVersion
+		ownResources = append(ownResources, ownIngress)
 	}
 	if instance.Spec.RelationResource.PVC != nil {
 		ownResources = append(ownResources, instance.Spec.RelationResource.PVC)
 	}
+	if instance.Spec.Category != customv1.CategoryStatefulSet {
+		// StatefulSet以外的Unit，所有pod共享volumes中的PVC
+		for i := range instance.Spec.RelationResource.Volumes {
+			ownResources = append(ownResources, &instance.Spec.RelationResource.Volumes[i])
+		}
+	}
+	// DaemonSet没有scale subresource，不创建PDB，webhook生效之前创建的PDB会随inventory清理
+	if instance.Spec.RelationResource.PDB != nil && instance.Spec.Category != customv1.CategoryDaemonSet {
+		ownResources = append(ownResources, instance.Spec.RelationResource.PDB)
+	}
+	// 集群没有安装Gateway API时无法创建HTTPRoute，跳过它，原因记录在HTTPRouteReady条件中
+	if instance.Spec.RelationResource.GatewayRoute != nil && r.gatewayRouteServed {
+		ownResources = append(ownResources, instance.Spec.RelationResource.GatewayRoute)
+	}
 	return ownResources, nil
 }
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	customv1 "Unit/api/v1"
+)
+
+// deletionPolicy为Orphan时，去掉Unit创建的所有对象上Unit的ownerReference，Unit回收后工作负载继续运行。
+// 对象来自status.inventory，以及按当前spec生成的own resource，兼容inventory还未记录的对象
+func (r *UnitReconciler) orphanOwnResources(instance *customv1.Unit) error {
+	var objects []runtime.Object
+	ownResources, err := r.getOwnResources(instance)
+	if err != nil {
+		msg := fmt.Sprintf("%s %s Reconciler.getOwnResource() function error", instance.Namespace, instance.Name)
+		r.Log.Error(err, msg)
+		return err
+	}
+	for _, ownResource := range ownResources {
+		exist, found, err := ownResource.OwnResourceExist(instance, r.Client, r.Log)
+		if err != nil {
+			// 集群没有安装对应的API(例如Gateway API)时，也就不存在需要保留的对象
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		if exist {
+			objects = append(objects, found.(runtime.Object))
+		}
+	}
+
+	for _, entry := range instance.Status.Inventory {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: entry.Group, Version: entry.Version, Kind: entry.Kind})
+		err := r.Get(context.TODO(), types.NamespacedName{Name: entry.Name, Namespace: instance.Namespace}, obj)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			msg := fmt.Sprintf("get %s %s/%s in Unit inventory error", entry.Kind, instance.Namespace, entry.Name)
+			r.Log.Error(err, msg)
+			return err
+		}
+		objects = append(objects, obj)
+	}
+
+	// 同一个对象可能同时出现在inventory和own resource中
+	orphaned := make(map[types.UID]bool)
+	for _, obj := range objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		if orphaned[accessor.GetUID()] {
+			continue
+		}
+		orphaned[accessor.GetUID()] = true
+		if err := r.orphanObject(instance, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 去掉单个对象上Unit的ownerReference，已经去掉的对象不做处理
+func (r *UnitReconciler) orphanObject(instance *customv1.Unit, obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	var ownerReferences []metav1.OwnerReference
+	for _, ownerReference := range accessor.GetOwnerReferences() {
+		if ownerReference.UID != instance.UID {
+			ownerReferences = append(ownerReferences, ownerReference)
+		}
+	}
+	if len(ownerReferences) == len(accessor.GetOwnerReferences()) {
+		return nil
+	}
+
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if entry, err := newInventoryEntry(obj, r.Scheme); err == nil {
+		kind = entry.Kind
+	}
+	patched := obj.DeepCopyObject()
+	patchedAccessor, _ := meta.Accessor(patched)
+	patchedAccessor.SetOwnerReferences(ownerReferences)
+	if err := r.Patch(context.TODO(), patched, client.MergeFrom(obj)); err != nil {
+		r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "Failed to orphan %s %s/%s, reason: %s, error: %v",
+			kind, instance.Namespace, accessor.GetName(), errors.ReasonForError(err), err)
+		return err
+	}
+	msg := fmt.Sprintf("%s %s/%s is orphaned from Unit %s", kind, instance.Namespace, accessor.GetName(), instance.Name)
+	r.Log.Info(msg)
+	r.recordEvent(instance, corev1.EventTypeNormal, "Orphaned", "Orphaned %s %s/%s", kind, instance.Namespace, accessor.GetName())
+	return nil
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	customv1 "Unit/api/v1"
+)
+
+var _ = Describe("Unit deletionPolicy", func() {
+	const namespace = "default"
+	ctx := context.Background()
+
+	newReconciler := func() *UnitReconciler {
+		return &UnitReconciler{
+			Client:            k8sClient,
+			Log:               logf.Log,
+			Scheme:            scheme.Scheme,
+			Recorder:          record.NewFakeRecorder(100),
+			IngressAPIVersion: customv1.IngressAPIVersionNetworkingV1beta1,
+		}
+	}
+
+	// 创建Unit及其Deployment和Service
+	createUnit := func(name, deletionPolicy string) *customv1.Unit {
+		instance := &customv1.Unit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: customv1.UnitSpec{
+				Category: customv1.CategoryDeployment,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx"}}},
+				},
+				RelationResource: customv1.UnitRelationResourceSpec{
+					Service: &customv1.OwnService{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+				},
+				DeletionPolicy: deletionPolicy,
+			},
+		}
+		instance.Default()
+		Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+
+		r := newReconciler()
+		ownResources, err := r.getOwnResources(instance)
+		Expect(err).NotTo(HaveOccurred())
+		for _, ownResource := range ownResources {
+			Expect(ownResource.ApplyOwnResource(instance, k8sClient, logf.Log, scheme.Scheme, nil)).To(Succeed())
+		}
+		return instance
+	}
+
+	It("should keep the controller reference with the default Delete policy", func() {
+		instance := createUnit("unit-deletion-delete", "")
+		defer func() {
+			Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+		}()
+		Expect(instance.Spec.DeletionPolicy).To(Equal(customv1.DeletionPolicyDelete))
+		Expect(instance.ValidateDelete()).To(Succeed())
+
+		waiting, err := newReconciler().PreDelete(instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(waiting).To(BeFalse())
+
+		deployment := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: namespace}, deployment)).To(Succeed())
+		Expect(metav1.IsControlledBy(deployment, instance)).To(BeTrue())
+	})
+
+	It("should strip the controller reference from children with the Orphan policy", func() {
+		instance := createUnit("unit-deletion-orphan", customv1.DeletionPolicyOrphan)
+		defer func() {
+			Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+		}()
+		key := types.NamespacedName{Name: instance.Name, Namespace: namespace}
+
+		waiting, err := newReconciler().PreDelete(instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(waiting).To(BeFalse())
+
+		deployment := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, key, deployment)).To(Succeed())
+		Expect(metav1.GetControllerOf(deployment)).To(BeNil())
+		service := &corev1.Service{}
+		Expect(k8sClient.Get(ctx, key, service)).To(Succeed())
+		Expect(metav1.GetControllerOf(service)).To(BeNil())
+
+		By("tolerating children that are already orphaned")
+		_, err = newReconciler().PreDelete(instance)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, service)).To(Succeed())
+	})
+
+	It("should refuse deletion with the Protect policy unless confirmed", func() {
+		instance := createUnit("unit-deletion-protect", customv1.DeletionPolicyProtect)
+		defer func() {
+			Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+		}()
+
+		Expect(instance.ValidateDelete()).NotTo(Succeed())
+
+		instance.Annotations = map[string]string{customv1.DeleteConfirmAnnotation: "true"}
+		Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+		Expect(instance.ValidateDelete()).To(Succeed())
+
+		waiting, err := newReconciler().PreDelete(instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(waiting).To(BeFalse())
+	})
+})
@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+
+	customv1 "Unit/api/v1"
+)
+
+// 预删除步骤(快照、优雅下线)未完成时的重新检查间隔
+const preDeleteRequeueInterval = 5 * time.Second
+
+// 优雅下线的默认等待时间
+const (
+	defaultDrainPeriodSeconds  int32 = 30
+	defaultDrainTimeoutSeconds int32 = 300
+)
+
+// Unit删除前的优雅下线，按 删除Ingress -> 等待drain period -> 缩容到0 -> 等待endpoints清空 的顺序逐步推进，
+// 每一步的进度记录在status.drain中。返回waiting为true时表示还未完成，需要稍后重新调谐，不在这里阻塞等待
+func (r *UnitReconciler) drainWorkload(instance *customv1.Unit) (waiting bool, err error) {
+	drain := instance.Spec.Drain
+	if drain == nil {
+		return false, nil
+	}
+	period := defaultDrainPeriodSeconds
+	if drain.PeriodSeconds != nil {
+		period = *drain.PeriodSeconds
+	}
+	timeout := defaultDrainTimeoutSeconds
+	if drain.TimeoutSeconds != nil {
+		timeout = *drain.TimeoutSeconds
+	}
+
+	ownResources, err := r.getOwnResources(instance)
+	if err != nil {
+		msg := fmt.Sprintf("%s %s Reconciler.getOwnResource() function error", instance.Namespace, instance.Name)
+		r.Log.Error(err, msg)
+		return false, err
+	}
+
+	current, elapsed := "", time.Duration(0)
+	if status := instance.Status.Drain; status != nil {
+		current, elapsed = status.Phase, time.Since(status.PhaseStartTime.Time)
+	}
+	// endpoints与Unit.status一致，集群提供EndpointSlice时从EndpointSlice获取
+	endpoints := 0
+	if current == customv1.DrainPhaseScaledDown {
+		if endpoints, err = r.countEndpoints(instance, ownResources); err != nil {
+			return false, err
+		}
+	}
+
+	next := nextDrainPhase(current, elapsed, time.Duration(period)*time.Second, time.Duration(timeout)*time.Second, endpoints)
+	if next == current {
+		if current == customv1.DrainPhaseScaledDown {
+			msg := fmt.Sprintf("waiting for %d endpoints of Unit %s/%s to be removed", endpoints, instance.Namespace, instance.Name)
+			r.Log.Info(msg)
+		}
+		return current != customv1.DrainPhaseCompleted, nil
+	}
+
+	switch next {
+	case customv1.DrainPhaseIngressRemoved:
+		// 1. 删除Ingress/HTTPRoute，外部流量不再进入
+		if err := r.removeIngress(instance, ownResources); err != nil {
+			return false, err
+		}
+		message := fmt.Sprintf("ingress removed, waiting %ds before scaling down", period)
+		return true, r.setDrainPhase(instance, next, message)
+
+	case customv1.DrainPhaseScaledDown:
+		// 2. 等待drain period后将工作负载缩容到0
+		if err := r.scaleToZero(instance, ownResources); err != nil {
+			return false, err
+		}
+		return true, r.setDrainPhase(instance, next, "workload scaled down to 0, waiting for endpoints to be empty")
+	}
+
+	// 3. endpoints清空，或超时后不再等待
+	if endpoints > 0 {
+		r.recordEvent(instance, corev1.EventTypeWarning, "DrainTimeout", "%d endpoints still exist after %ds, continue deleting",
+			endpoints, timeout)
+	}
+	return false, r.setDrainPhase(instance, customv1.DrainPhaseCompleted, "drain completed")
+}
+
+// 根据当前阶段和已经等待的时间计算下一个阶段，返回当前阶段时表示继续等待。
+// current为空表示还未开始下线；endpoints只在ScaledDown阶段使用
+func nextDrainPhase(current string, elapsed, period, timeout time.Duration, endpoints int) string {
+	switch current {
+	case "":
+		return customv1.DrainPhaseIngressRemoved
+	case customv1.DrainPhaseIngressRemoved:
+		if elapsed < period {
+			return current
+		}
+		return customv1.DrainPhaseScaledDown
+	case customv1.DrainPhaseScaledDown:
+		if endpoints > 0 && elapsed < timeout {
+			return current
+		}
+	}
+	return customv1.DrainPhaseCompleted
+}
+
+// 更新status.drain，记录进入新阶段的时间
+func (r *UnitReconciler) setDrainPhase(instance *customv1.Unit, phase, message string) error {
+	instance.Status.Drain = &customv1.UnitDrainStatus{
+		Phase:          phase,
+		Message:        message,
+		PhaseStartTime: metav1.Now(),
+	}
+	if err := r.Status().Update(context.TODO(), instance); err != nil {
+		msg := fmt.Sprintf("update Unit %s/%s drain status error", instance.Namespace, instance.Name)
+		r.Log.Error(err, msg)
+		return err
+	}
+	r.recordEvent(instance, corev1.EventTypeNormal, "Draining", "Drain %s: %s", phase, message)
+	return nil
+}
+
+// 删除Unit的Ingress和HTTPRoute
+func (r *UnitReconciler) removeIngress(instance *customv1.Unit, ownResources []OwnResource) error {
+	for _, ownResource := range ownResources {
+		switch ownResource.(type) {
+		case *customv1.OwnIngress, *customv1.OwnGatewayRoute:
+		default:
+			continue
+		}
+		exist, found, err := ownResource.OwnResourceExist(instance, r.Client, r.Log)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		if !exist {
+			continue
+		}
+		obj := found.(runtime.Object)
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(accessor, instance) {
+			continue
+		}
+
+		kind := ownResourceKind(ownResource)
+		if err := r.Delete(context.TODO(), obj); err != nil && !errors.IsNotFound(err) {
+			r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "Failed to delete %s %s/%s, reason: %s, error: %v",
+				kind, instance.Namespace, accessor.GetName(), errors.ReasonForError(err), err)
+			return err
+		}
+		r.recordEvent(instance, corev1.EventTypeNormal, customv1.EventReasonDeleted, "%s %s %s/%s before draining",
+			customv1.EventReasonDeleted, kind, instance.Namespace, accessor.GetName())
+	}
+	return nil
+}
+
+// 将Deployment/StatefulSet缩容到0。DaemonSet/Job/CronJob无法缩容，不做处理
+func (r *UnitReconciler) scaleToZero(instance *customv1.Unit, ownResources []OwnResource) error {
+	zero := int32(0)
+	for _, ownResource := range ownResources {
+		switch ownResource.(type) {
+		case *customv1.OwnDeployment, *customv1.OwnStatefulSet:
+		default:
+			continue
+		}
+		exist, found, err := ownResource.OwnResourceExist(instance, r.Client, r.Log)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			continue
+		}
+
+		var obj, patched runtime.Object
+		switch workload := found.(type) {
+		case *appsv1.Deployment:
+			if workload.Spec.Replicas != nil && *workload.Spec.Replicas == 0 {
+				continue
+			}
+			scaled := workload.DeepCopy()
+			scaled.Spec.Replicas = &zero
+			obj, patched = workload, scaled
+		case *appsv1.StatefulSet:
+			if workload.Spec.Replicas != nil && *workload.Spec.Replicas == 0 {
+				continue
+			}
+			scaled := workload.DeepCopy()
+			scaled.Spec.Replicas = &zero
+			obj, patched = workload, scaled
+		default:
+			continue
+		}
+
+		kind := ownResourceKind(ownResource)
+		if err := r.Patch(context.TODO(), patched, client.MergeFrom(obj)); err != nil {
+			r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "Failed to scale %s %s/%s to 0, reason: %s, error: %v",
+				kind, instance.Namespace, instance.Name, errors.ReasonForError(err), err)
+			return err
+		}
+		msg := fmt.Sprintf("%s %s/%s is scaled to 0 before Unit deleted", kind, instance.Namespace, instance.Name)
+		r.Log.Info(msg)
+	}
+	return nil
+}
+
+// 统计Unit所有Service的endpoints数量，包括未就绪的地址，与status.relationResourceStatus.endpoint使用同样的数据
+func (r *UnitReconciler) countEndpoints(instance *customv1.Unit, ownResources []OwnResource) (int, error) {
+	count := 0
+	for _, ownResource := range ownResources {
+		ownService, ok := ownResource.(*customv1.OwnService)
+		if !ok {
+			continue
+		}
+		endpoints, err := ownService.EndpointsStatus(instance, r.Client)
+		if err != nil {
+			msg := fmt.Sprintf("get endpoints of Service %s/%s error", instance.Namespace, ownService.ServiceName(instance))
+			r.Log.Error(err, msg)
+			return 0, err
+		}
+		count += len(endpoints)
+	}
+	return count, nil
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	customv1 "Unit/api/v1"
+)
+
+func TestNextDrainPhase(t *testing.T) {
+	period := 30 * time.Second
+	timeout := 300 * time.Second
+
+	cases := []struct {
+		name      string
+		current   string
+		elapsed   time.Duration
+		endpoints int
+		want      string
+	}{
+		{name: "start draining", current: "", want: customv1.DrainPhaseIngressRemoved},
+		{name: "waiting for drain period", current: customv1.DrainPhaseIngressRemoved, elapsed: 10 * time.Second,
+			want: customv1.DrainPhaseIngressRemoved},
+		{name: "drain period passed", current: customv1.DrainPhaseIngressRemoved, elapsed: period,
+			want: customv1.DrainPhaseScaledDown},
+		{name: "waiting for endpoints", current: customv1.DrainPhaseScaledDown, elapsed: 10 * time.Second, endpoints: 2,
+			want: customv1.DrainPhaseScaledDown},
+		{name: "endpoints removed", current: customv1.DrainPhaseScaledDown, elapsed: 10 * time.Second,
+			want: customv1.DrainPhaseCompleted},
+		{name: "endpoints timeout", current: customv1.DrainPhaseScaledDown, elapsed: timeout, endpoints: 2,
+			want: customv1.DrainPhaseCompleted},
+		{name: "completed", current: customv1.DrainPhaseCompleted, elapsed: time.Hour,
+			want: customv1.DrainPhaseCompleted},
+	}
+	for _, c := range cases {
+		if got := nextDrainPhase(c.current, c.elapsed, period, timeout, c.endpoints); got != c.want {
+			t.Errorf("%s: nextDrainPhase = %s, want %s", c.name, got, c.want)
+		}
+	}
+}
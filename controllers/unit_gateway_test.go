@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	customv1 "Unit/api/v1"
+)
+
+var _ = Describe("Unit gatewayRoute", func() {
+	const (
+		name      = "unit-gateway"
+		namespace = "default"
+	)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: name, Namespace: namespace}
+
+	It("should own a HTTPRoute and surface its conditions in Unit status", func() {
+		port := int32(8080)
+		weight := int32(90)
+		instance := &customv1.Unit{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: customv1.UnitSpec{
+				Category: customv1.CategoryDeployment,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx"}}},
+				},
+				RelationResource: customv1.UnitRelationResourceSpec{
+					Service: &customv1.OwnService{Ports: []corev1.ServicePort{{Name: "http", Port: port}}},
+					GatewayRoute: &customv1.OwnGatewayRoute{
+						ParentRefs: []customv1.GatewayParentRef{{Name: "public"}},
+						Hostnames:  []string{"unit.example.com"},
+						Rules: []customv1.GatewayRouteRule{{
+							Matches: []customv1.GatewayRouteMatch{{
+								Path:    &customv1.GatewayPathMatch{Value: "/api"},
+								Headers: []customv1.GatewayHeaderMatch{{Name: "x-canary", Value: "true"}},
+							}},
+							BackendRefs: []customv1.GatewayBackendRef{{Port: &port, Weight: &weight}},
+						}},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+		}()
+
+		By("applying the HTTPRoute")
+		ownRoute := instance.Spec.RelationResource.GatewayRoute
+		Expect(ownRoute.ApplyOwnResource(instance, k8sClient, logf.Log, scheme.Scheme, nil)).To(Succeed())
+
+		route := &unstructured.Unstructured{}
+		route.SetAPIVersion(customv1.GatewayAPIVersion)
+		route.SetKind(customv1.HTTPRouteKind)
+		Expect(k8sClient.Get(ctx, key, route)).To(Succeed())
+		Expect(metav1.IsControlledBy(route, instance)).To(BeTrue())
+
+		hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+		Expect(hostnames).To(Equal([]string{"unit.example.com"}))
+		rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+		Expect(rules).To(HaveLen(1))
+		rule := rules[0].(map[string]interface{})
+		Expect(rule["matches"]).To(ConsistOf(map[string]interface{}{
+			"path":    map[string]interface{}{"type": "PathPrefix", "value": "/api"},
+			"headers": []interface{}{map[string]interface{}{"type": "Exact", "name": "x-canary", "value": "true"}},
+		}))
+		Expect(rule["backendRefs"]).To(ConsistOf(map[string]interface{}{
+			"name": name, "port": int64(port), "weight": int64(weight),
+		}))
+
+		By("surfacing the conditions reported by the Gateway controller")
+		Expect(unstructured.SetNestedSlice(route.Object, []interface{}{
+			map[string]interface{}{
+				"parentRef":      map[string]interface{}{"name": "public"},
+				"controllerName": "example.com/gateway-controller",
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Accepted", "status": "True", "reason": "Accepted",
+						"message": "", "lastTransitionTime": "2020-01-01T00:00:00Z"},
+					map[string]interface{}{"type": "ResolvedRefs", "status": "False", "reason": "BackendNotFound",
+						"message": "service not found", "lastTransitionTime": "2020-01-01T00:00:00Z"},
+				},
+			},
+		}, "status", "parents")).To(Succeed())
+		Expect(k8sClient.Status().Update(ctx, route)).To(Succeed())
+
+		updated, err := ownRoute.UpdateOwnResourceStatus(instance, k8sClient, logf.Log)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Status.RelationResourceStatus.GatewayRoute).To(HaveLen(1))
+		parent := updated.Status.RelationResourceStatus.GatewayRoute[0]
+		Expect(parent.Gateway).To(Equal(namespace + "/public"))
+		Expect(parent.ControllerName).To(Equal("example.com/gateway-controller"))
+		Expect(parent.Conditions).To(HaveLen(2))
+		Expect(parent.Conditions[0].Type).To(Equal("Accepted"))
+		Expect(parent.Conditions[0].Status).To(Equal(corev1.ConditionTrue))
+		Expect(parent.Conditions[1].Type).To(Equal("ResolvedRefs"))
+		Expect(parent.Conditions[1].Reason).To(Equal("BackendNotFound"))
+	})
+})
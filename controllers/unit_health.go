@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"net"
+	"strconv"
+	"time"
+
+	customv1 "Unit/api/v1"
+	"Unit/controllers/healthcheck"
+)
+
+const (
+	defaultHealthCheckPeriod  = 10 * time.Second
+	defaultHealthCheckTimeout = time.Second
+)
+
+// 根据各Service的status和healthChecks更新后台的主动检查目标，并把缓存的最近一次检查结果写入Service的ports status，
+// 覆盖根据endpoint就绪状态得出的健康状态。调谐本身不做任何网络检查
+func (r *UnitReconciler) syncPortHealth(instance *customv1.Unit, ownResources []OwnResource) {
+	if r.healthProber == nil {
+		return
+	}
+	owner := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+
+	var targets []healthcheck.Target
+	for _, ownResource := range ownResources {
+		ownService, ok := ownResource.(*customv1.OwnService)
+		if !ok {
+			continue
+		}
+		serviceStatus := findServiceStatus(instance, ownService.Name)
+		if serviceStatus == nil {
+			continue
+		}
+
+		// headless service没有clusterIP，直接检查第一个就绪的pod
+		headless := serviceStatus.ClusterIP == "" || serviceStatus.ClusterIP == corev1.ClusterIPNone
+		var endpoints []customv1.UnitRelationEndpointStatus
+		endpointsLoaded := false
+		for i := range serviceStatus.Ports {
+			portStatus := &serviceStatus.Ports[i]
+			check := ownService.PortHealthCheck(portStatus.ServicePort)
+			if check == nil {
+				continue
+			}
+			address := net.JoinHostPort(serviceStatus.ClusterIP, strconv.Itoa(int(portStatus.Port)))
+			if headless {
+				if !endpointsLoaded {
+					var err error
+					if endpoints, err = ownService.EndpointsStatus(instance, r.Client); err != nil {
+						msg := fmt.Sprintf("get endpoints of Service %s/%s failed", instance.Namespace, ownService.ServiceName(instance))
+						r.Log.Error(err, msg)
+						break
+					}
+					endpointsLoaded = true
+				}
+				podIP, port, ok := customv1.ReadyEndpointPort(endpoints, portStatus.ServicePort)
+				if !ok {
+					// 没有就绪的pod时不检查，健康状态保持为endpoint就绪状态
+					continue
+				}
+				address = net.JoinHostPort(podIP, strconv.Itoa(int(port)))
+			}
+			key := fmt.Sprintf("%s/%d", ownService.ServiceName(instance), portStatus.Port)
+			targets = append(targets, newHealthCheckTarget(key, address, check))
+
+			if result, ok := r.healthProber.Result(owner, key); ok {
+				checkTime := metav1.NewTime(result.CheckTime)
+				portStatus.Health = result.Healthy
+				portStatus.LastCheckTime = &checkTime
+				portStatus.LatencyMilliseconds = result.Latency.Milliseconds()
+				portStatus.Message = result.Message
+			}
+		}
+	}
+	r.healthProber.Sync(owner, targets)
+}
+
+// Unit被删除时停止它的所有端口检查
+func (r *UnitReconciler) stopPortHealth(owner types.NamespacedName) {
+	if r.healthProber != nil {
+		r.healthProber.Remove(owner)
+	}
+}
+
+// serviceInfo中的主Service状态在status.relationResourceStatus.service，其它的在services中
+func findServiceStatus(instance *customv1.Unit, name string) *customv1.UnitRelationServiceStatus {
+	status := &instance.Status.RelationResourceStatus
+	if name == "" {
+		if len(status.Service.Ports) == 0 {
+			return nil
+		}
+		return &status.Service
+	}
+	for i := range status.Services {
+		if status.Services[i].Name == name {
+			return &status.Services[i]
+		}
+	}
+	return nil
+}
+
+func newHealthCheckTarget(key, address string, check *customv1.ServicePortHealthCheck) healthcheck.Target {
+	target := healthcheck.Target{
+		Key:     key,
+		Address: address,
+		Period:  defaultHealthCheckPeriod,
+		Timeout: defaultHealthCheckTimeout,
+	}
+	if check.PeriodSeconds > 0 {
+		target.Period = time.Duration(check.PeriodSeconds) * time.Second
+	}
+	if check.TimeoutSeconds > 0 {
+		target.Timeout = time.Duration(check.TimeoutSeconds) * time.Second
+	}
+
+	switch check.Type {
+	case customv1.HealthCheckHTTP:
+		checker := &healthcheck.HTTPChecker{Path: "/"}
+		if httpGet := check.HTTPGet; httpGet != nil {
+			checker.Scheme = httpGet.Scheme
+			if httpGet.Path != "" {
+				checker.Path = httpGet.Path
+			}
+			for _, status := range httpGet.ExpectedStatus {
+				checker.ExpectedStatus = append(checker.ExpectedStatus, int(status))
+			}
+		}
+		target.Checker = checker
+	case customv1.HealthCheckGRPC:
+		checker := &healthcheck.GRPCChecker{}
+		if check.GRPC != nil {
+			checker.Service = check.GRPC.Service
+		}
+		target.Checker = checker
+	default:
+		target.Checker = &healthcheck.TCPChecker{}
+	}
+	return target
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sort"
+
+	customv1 "Unit/api/v1"
+)
+
+// 从inventory中移除时不做删除的资源类型：
+// 工作负载在category变更时由migrateWorkload等待新工作负载就绪后再删除；PVC涉及数据，随Unit删除时按retainPolicy处理
+var inventoryPruneExcluded = map[schema.GroupKind]bool{
+	{Group: "apps", Kind: "Deployment"}:        true,
+	{Group: "apps", Kind: "StatefulSet"}:       true,
+	{Group: "apps", Kind: "DaemonSet"}:         true,
+	{Group: "batch", Kind: "Job"}:              true,
+	{Group: "batch", Kind: "CronJob"}:          true,
+	{Group: "", Kind: "PersistentVolumeClaim"}: true,
+}
+
+// inventory条目的key，不包含version，API版本升级(例如Ingress v1beta1 -> v1)不视为不同的对象
+func inventoryKey(entry customv1.UnitInventoryEntry) string {
+	return fmt.Sprintf("%s/%s/%s", entry.Group, entry.Kind, entry.Name)
+}
+
+func newInventoryEntry(obj runtime.Object, scheme *runtime.Scheme) (customv1.UnitInventoryEntry, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return customv1.UnitInventoryEntry{}, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return customv1.UnitInventoryEntry{}, err
+	}
+	return customv1.UnitInventoryEntry{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+		Name:    accessor.GetName(),
+		UID:     accessor.GetUID(),
+	}, nil
+}
+
+// 根据getOwnResources的结果，生成当前spec期望的所有对象的key
+func (r *UnitReconciler) desiredInventoryKeys(instance *customv1.Unit, ownResources []OwnResource) (map[string]bool, error) {
+	desired := make(map[string]bool)
+	for _, ownResource := range ownResources {
+		obj, err := ownResource.MakeOwnResource(instance, r.Log, r.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := newInventoryEntry(obj.(runtime.Object), r.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		desired[inventoryKey(entry)] = true
+	}
+	return desired, nil
+}
+
+// ApplyOwnResource成功后，获取own resource的最新对象生成inventory条目。
+// 同一个对象的UID与上一次记录的不同，说明它在Unit之外被删除过，已经被重新创建
+func (r *UnitReconciler) inventoryEntry(instance *customv1.Unit, ownResource OwnResource) (*customv1.UnitInventoryEntry, error) {
+	exist, found, err := ownResource.OwnResourceExist(instance, r.Client, r.Log)
+	if err != nil || !exist {
+		return nil, err
+	}
+	entry, err := newInventoryEntry(found.(runtime.Object), r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, old := range instance.Status.Inventory {
+		if inventoryKey(old) == inventoryKey(entry) && old.UID != "" && old.UID != entry.UID {
+			msg := fmt.Sprintf("%s %s/%s was deleted outside of Unit %s, recreated", entry.Kind, instance.Namespace, entry.Name, instance.Name)
+			r.Log.Info(msg)
+			r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonRecreated, "%s %s/%s was deleted outside of Unit, recreated",
+				entry.Kind, instance.Namespace, entry.Name)
+		}
+	}
+	return &entry, nil
+}
+
+// 删除inventory中记录、但当前spec不再生成的对象。
+// 只删除UID一致且controller为当前Unit的对象，带有 custom.my.crd.com/keep: "true" annotation的对象保留并去掉Unit的ownerReference。
+// 返回删除或保留的记录，以及仍需留在inventory中的条目(删除失败或不做删除的类型)，出错时继续处理其余条目
+func (r *UnitReconciler) pruneInventory(instance *customv1.Unit, entries []customv1.UnitInventoryEntry,
+	desired map[string]bool) ([]customv1.UnitRemovedResource, []customv1.UnitInventoryEntry, error) {
+
+	var removed []customv1.UnitRemovedResource
+	var remaining []customv1.UnitInventoryEntry
+	var pruneErr error
+	for _, entry := range entries {
+		if desired[inventoryKey(entry)] {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: entry.Group, Version: entry.Version, Kind: entry.Kind})
+		err := r.Get(context.TODO(), types.NamespacedName{Name: entry.Name, Namespace: instance.Namespace}, obj)
+		if err != nil {
+			// 对应的API已经不存在(例如卸载了Gateway API)时，对象也随之删除
+			if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			msg := fmt.Sprintf("get %s %s/%s in Unit inventory error", entry.Kind, instance.Namespace, entry.Name)
+			r.Log.Error(err, msg)
+			pruneErr = err
+			remaining = append(remaining, entry)
+			continue
+		}
+		// 同名对象已被重建，或者不再归当前Unit管理
+		if obj.GetUID() != entry.UID || !metav1.IsControlledBy(obj, instance) {
+			continue
+		}
+		if inventoryPruneExcluded[schema.GroupKind{Group: entry.Group, Kind: entry.Kind}] {
+			remaining = append(remaining, entry)
+			continue
+		}
+		// 保留的对象去掉Unit的ownerReference，之后不再归Unit管理，也不会随Unit删除被gc回收
+		if obj.GetAnnotations()[customv1.KeepResourceAnnotation] == "true" {
+			if err := r.orphanObject(instance, obj); err != nil {
+				pruneErr = err
+				remaining = append(remaining, entry)
+				continue
+			}
+			removed = append(removed, customv1.UnitRemovedResource{Kind: entry.Kind, Name: entry.Name,
+				Action: customv1.RemovedResourceKept, Time: metav1.Now()})
+			continue
+		}
+
+		msg := fmt.Sprintf("%s %s/%s is no longer produced by Unit %s, delete it", entry.Kind, instance.Namespace, entry.Name, instance.Name)
+		r.Log.Info(msg)
+		if err := r.Delete(context.TODO(), obj); err != nil && !errors.IsNotFound(err) {
+			r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "Failed to delete %s %s/%s, reason: %s, error: %v",
+				entry.Kind, instance.Namespace, entry.Name, errors.ReasonForError(err), err)
+			pruneErr = err
+			remaining = append(remaining, entry)
+			continue
+		}
+		r.recordEvent(instance, corev1.EventTypeNormal, customv1.EventReasonDeleted, "%s %s %s/%s, removed from spec",
+			customv1.EventReasonDeleted, entry.Kind, instance.Namespace, entry.Name)
+		removed = append(removed, customv1.UnitRemovedResource{Kind: entry.Kind, Name: entry.Name,
+			Action: customv1.RemovedResourceDeleted, Time: metav1.Now()})
+	}
+	return removed, remaining, pruneErr
+}
+
+// 合并本次调谐得到的inventory：本次apply成功的条目、apply失败但仍期望存在的旧条目，以及prune后仍需保留的条目
+func mergeInventory(instance *customv1.Unit, applied []customv1.UnitInventoryEntry, desired map[string]bool,
+	remaining []customv1.UnitInventoryEntry) []customv1.UnitInventoryEntry {
+
+	inventory := make(map[string]customv1.UnitInventoryEntry)
+	for _, entry := range instance.Status.Inventory {
+		if desired[inventoryKey(entry)] {
+			inventory[inventoryKey(entry)] = entry
+		}
+	}
+	for _, entry := range remaining {
+		inventory[inventoryKey(entry)] = entry
+	}
+	for _, entry := range applied {
+		inventory[inventoryKey(entry)] = entry
+	}
+
+	result := make([]customv1.UnitInventoryEntry, 0, len(inventory))
+	for _, entry := range inventory {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return inventoryKey(result[i]) < inventoryKey(result[j])
+	})
+	return result
+}
+
+// 清理spec不再生成的对象，并返回新的inventory，inventory是清理own resource的依据。
+// inventory为空时(例如inventory引入之前创建的Unit)，按controller reference找回Unit拥有的关联资源作为清理对象。
+// 生成期望对象出错时保留原来的inventory，不做删除。
+// deferPrune为true时(category迁移期间旧工作负载仍在提供服务)，它依赖的对象(例如StatefulSet的headless service)暂不删除，迁移完成后再清理
+func (r *UnitReconciler) syncInventory(instance *customv1.Unit, ownResources []OwnResource,
+	applied []customv1.UnitInventoryEntry, deferPrune bool) ([]customv1.UnitInventoryEntry, []customv1.UnitRemovedResource, error) {
+
+	desired, err := r.desiredInventoryKeys(instance, ownResources)
+	if err != nil {
+		msg := fmt.Sprintf("make own resources of Unit %s/%s for inventory error", instance.Namespace, instance.Name)
+		r.Log.Error(err, msg)
+		return instance.Status.Inventory, nil, err
+	}
+
+	entries := instance.Status.Inventory
+	if len(entries) == 0 {
+		if entries, err = r.controlledInventory(instance); err != nil {
+			return mergeInventory(instance, applied, desired, nil), nil, err
+		}
+	}
+
+	if deferPrune {
+		msg := fmt.Sprintf("Unit %s/%s is migrating, defer pruning inventory", instance.Namespace, instance.Name)
+		r.Log.Info(msg)
+		return mergeInventory(instance, applied, desired, entries), nil, nil
+	}
+
+	removed, remaining, err := r.pruneInventory(instance, entries, desired)
+	return mergeInventory(instance, applied, desired, remaining), removed, err
+}
@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sort"
+	"testing"
+
+	customv1 "Unit/api/v1"
+)
+
+func newInventoryTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := customv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func TestMergeInventory(t *testing.T) {
+	deployment := customv1.UnitInventoryEntry{Group: "apps", Version: "v1", Kind: "Deployment", Name: "unit", UID: "d1"}
+	service := customv1.UnitInventoryEntry{Version: "v1", Kind: "Service", Name: "unit", UID: "s1"}
+	oldIngress := customv1.UnitInventoryEntry{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress", Name: "unit", UID: "i1"}
+	newIngress := customv1.UnitInventoryEntry{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress", Name: "unit", UID: "i1"}
+	pdb := customv1.UnitInventoryEntry{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget", Name: "unit", UID: "p1"}
+
+	instance := &customv1.Unit{}
+	instance.Status.Inventory = []customv1.UnitInventoryEntry{deployment, service, oldIngress, pdb}
+	desired := map[string]bool{
+		inventoryKey(deployment): true,
+		inventoryKey(service):    true,
+		inventoryKey(newIngress): true,
+	}
+
+	// Service apply失败，沿用旧条目；Ingress升级API版本后key不变，使用新条目；PDB不再期望且已删除
+	got := mergeInventory(instance, []customv1.UnitInventoryEntry{deployment, newIngress}, desired, nil)
+	// 按group/kind/name排序
+	want := []customv1.UnitInventoryEntry{service, deployment, newIngress}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("mergeInventory = %v, want %v", got, want)
+	}
+
+	// 删除失败的PDB留在inventory中，下次调谐继续清理
+	got = mergeInventory(instance, []customv1.UnitInventoryEntry{deployment, newIngress}, desired, []customv1.UnitInventoryEntry{pdb})
+	want = []customv1.UnitInventoryEntry{service, deployment, newIngress, pdb}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("mergeInventory with remaining = %v, want %v", got, want)
+	}
+}
+
+func TestPruneInventory(t *testing.T) {
+	scheme := newInventoryTestScheme(t)
+	instance := &customv1.Unit{ObjectMeta: metav1.ObjectMeta{Name: "unit", Namespace: "default", UID: "unit-uid"}}
+	instance.Spec.Category = customv1.CategoryDeployment
+
+	owned := func(obj metav1.Object) {
+		if err := controllerutil.SetControllerReference(instance, obj, scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newService := func(name string, uid types.UID, annotations map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: uid, Annotations: annotations}}
+	}
+	desiredService := newService("unit", "s1", nil)
+	removedService := newService("unit-metrics", "s2", nil)
+	keptService := newService("unit-admin", "s3", map[string]string{customv1.KeepResourceAnnotation: "true"})
+	recreatedService := newService("unit-debug", "s4-new", nil)
+	foreignService := newService("unit-other", "s5", nil)
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "unit-data", Namespace: "default", UID: "v1"}}
+	for _, obj := range []metav1.Object{desiredService, removedService, keptService, recreatedService, pvc} {
+		owned(obj)
+	}
+
+	objs := []runtime.Object{desiredService, removedService, keptService, recreatedService, foreignService, pvc}
+	r := &UnitReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, objs...),
+		Log:    logf.Log.WithName("test"),
+		Scheme: scheme,
+	}
+
+	var inventory []customv1.UnitInventoryEntry
+	for _, obj := range objs {
+		entry, err := newInventoryEntry(obj, scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Name == "unit-debug" {
+			entry.UID = "s4-old"
+		}
+		inventory = append(inventory, entry)
+	}
+	// 已经不存在的对象直接从inventory中移除
+	inventory = append(inventory, customv1.UnitInventoryEntry{Version: "v1", Kind: "Service", Name: "unit-gone", UID: "s6"})
+	instance.Status.Inventory = inventory
+
+	desired := map[string]bool{"/Service/unit": true}
+	removed, remaining, err := r.pruneInventory(instance, instance.Status.Inventory, desired)
+	if err != nil {
+		t.Fatalf("pruneInventory error: %v", err)
+	}
+
+	var removedNames []string
+	for _, resource := range removed {
+		if resource.Kind != "Service" {
+			t.Errorf("removed resource %s has kind %s, want Service", resource.Name, resource.Kind)
+		}
+		removedNames = append(removedNames, resource.Name+":"+resource.Action)
+	}
+	wantRemoved := []string{"unit-metrics:" + customv1.RemovedResourceDeleted, "unit-admin:" + customv1.RemovedResourceKept}
+	sort.Strings(removedNames)
+	sort.Strings(wantRemoved)
+	if !reflect.DeepEqual(removedNames, wantRemoved) {
+		t.Errorf("removed = %v, want %v", removedNames, wantRemoved)
+	}
+	if len(remaining) != 1 || remaining[0].Kind != "PersistentVolumeClaim" {
+		t.Errorf("remaining = %v, want only the PersistentVolumeClaim", remaining)
+	}
+
+	ctx := context.Background()
+	if err := r.Get(ctx, types.NamespacedName{Name: "unit-metrics", Namespace: "default"}, &corev1.Service{}); !errors.IsNotFound(err) {
+		t.Errorf("expected Service unit-metrics to be deleted, got %v", err)
+	}
+	kept := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "unit-admin", Namespace: "default"}, kept); err != nil {
+		t.Fatalf("expected Service unit-admin to be kept, got %v", err)
+	}
+	if len(kept.OwnerReferences) != 0 {
+		t.Errorf("expected ownerReference of kept Service to be removed, got %v", kept.OwnerReferences)
+	}
+	for _, name := range []string{"unit", "unit-debug", "unit-other"} {
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &corev1.Service{}); err != nil {
+			t.Errorf("expected Service %s to be untouched, got %v", name, err)
+		}
+	}
+}
+
+func TestSyncInventoryAfterMigration(t *testing.T) {
+	scheme := newInventoryTestScheme(t)
+	replicas := int32(2)
+	instance := &customv1.Unit{ObjectMeta: metav1.ObjectMeta{Name: "unit", Namespace: "default", UID: "unit-uid"}}
+	instance.Spec.Category = customv1.CategoryDeployment
+	instance.Spec.Replicas = &replicas
+
+	objectMeta := func(name string, uid types.UID) metav1.ObjectMeta {
+		meta := metav1.ObjectMeta{Name: name, Namespace: "default", UID: uid, Generation: 1}
+		if err := controllerutil.SetControllerReference(instance, &meta, scheme); err != nil {
+			t.Fatal(err)
+		}
+		return meta
+	}
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: objectMeta("unit", "ss1")}
+	deployment := &appsv1.Deployment{ObjectMeta: objectMeta("unit", "d1"),
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 1}}
+	service := &corev1.Service{ObjectMeta: objectMeta("unit", "s1")}
+	// category变更前由StatefulSet使用的Service，迁移完成后不再期望
+	peerService := &corev1.Service{ObjectMeta: objectMeta("unit-peer", "s2")}
+
+	r := &UnitReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, statefulSet, deployment, service, peerService),
+		Log:    logf.Log.WithName("test"),
+		Scheme: scheme,
+	}
+	ownResources := []OwnResource{&customv1.OwnDeployment{}, &customv1.OwnService{}}
+	var applied []customv1.UnitInventoryEntry
+	for _, obj := range []runtime.Object{deployment, service} {
+		entry, err := newInventoryEntry(obj, scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		applied = append(applied, entry)
+	}
+	for _, obj := range []runtime.Object{statefulSet, service, peerService} {
+		entry, err := newInventoryEntry(obj, scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		instance.Status.Inventory = append(instance.Status.Inventory, entry)
+	}
+
+	// 与Reconcile的顺序一致：先迁移工作负载，再按迁移结果决定是否清理inventory
+	reconcile := func() []customv1.UnitRemovedResource {
+		migration, migrating, err := r.migrateWorkload(instance)
+		if err != nil {
+			t.Fatalf("migrateWorkload error: %v", err)
+		}
+		inventory, removed, err := r.syncInventory(instance, ownResources, applied, migrating)
+		if err != nil {
+			t.Fatalf("syncInventory error: %v", err)
+		}
+		instance.Status.Migration = migration
+		instance.Status.Inventory = inventory
+		return removed
+	}
+	ctx := context.Background()
+	peerKey := types.NamespacedName{Name: "unit-peer", Namespace: "default"}
+
+	// 新的Deployment还未就绪，旧的StatefulSet和它使用的Service都保留
+	if removed := reconcile(); len(removed) != 0 {
+		t.Fatalf("expected pruning to be deferred during migration, removed %v", removed)
+	}
+	if err := r.Get(ctx, peerKey, &corev1.Service{}); err != nil {
+		t.Fatalf("expected Service unit-peer to be kept during migration, got %v", err)
+	}
+
+	// Deployment就绪后删除StatefulSet，同一次调谐中恢复清理
+	deployment.Status.UpdatedReplicas = replicas
+	deployment.Status.AvailableReplicas = replicas
+	if err := r.Status().Update(ctx, deployment); err != nil {
+		t.Fatal(err)
+	}
+	removed := reconcile()
+	if len(removed) != 1 || removed[0].Name != "unit-peer" || removed[0].Action != customv1.RemovedResourceDeleted {
+		t.Fatalf("expected Service unit-peer to be removed after migration, removed %v", removed)
+	}
+	if err := r.Get(ctx, peerKey, &corev1.Service{}); !errors.IsNotFound(err) {
+		t.Errorf("expected Service unit-peer to be deleted, got %v", err)
+	}
+	for _, entry := range instance.Status.Inventory {
+		if entry.Kind == customv1.CategoryStatefulSet || entry.Name == "unit-peer" {
+			t.Errorf("expected %s %s to be dropped from inventory, got %v", entry.Kind, entry.Name, instance.Status.Inventory)
+		}
+	}
+
+	// 迁移完成后的调谐不再推迟清理，inventory保持不变
+	want := instance.Status.Inventory
+	if removed := reconcile(); len(removed) != 0 || !reflect.DeepEqual(instance.Status.Inventory, want) {
+		t.Errorf("expected a stable inventory, removed %v, inventory %v", removed, instance.Status.Inventory)
+	}
+}
+
+func TestSyncInventoryWithoutInventory(t *testing.T) {
+	scheme := newInventoryTestScheme(t)
+	instance := &customv1.Unit{ObjectMeta: metav1.ObjectMeta{Name: "unit", Namespace: "default", UID: "unit-uid"}}
+	instance.Spec.Category = customv1.CategoryDeployment
+
+	newService := func(name string, uid types.UID, owned bool) *corev1.Service {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: uid}}
+		if owned {
+			if err := controllerutil.SetControllerReference(instance, service, scheme); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return service
+	}
+	service := newService("unit", "s1", true)
+	r := &UnitReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, service, newService("unit-old", "s2", true), newService("unit-other", "s3", false)),
+		Log:    logf.Log.WithName("test"),
+		Scheme: scheme,
+
+		IngressAPIVersion: "extensions/v1beta1",
+	}
+	entry, err := newInventoryEntry(service, scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有inventory时，按controller reference找回inventory引入之前创建的对象
+	inventory, removed, err := r.syncInventory(instance, []OwnResource{&customv1.OwnService{}}, []customv1.UnitInventoryEntry{entry}, false)
+	if err != nil {
+		t.Fatalf("syncInventory error: %v", err)
+	}
+	if len(removed) != 1 || removed[0].Name != "unit-old" {
+		t.Errorf("expected only Service unit-old to be removed, removed %v", removed)
+	}
+	if !reflect.DeepEqual(inventory, []customv1.UnitInventoryEntry{entry}) {
+		t.Errorf("inventory = %v, want %v", inventory, []customv1.UnitInventoryEntry{entry})
+	}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "unit-other", Namespace: "default"}, &corev1.Service{}); err != nil {
+		t.Errorf("expected Service unit-other not controlled by Unit to be untouched, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+
+	customv1 "Unit/api/v1"
+)
+
+// 迁移等待新工作负载就绪期间，重新调谐的间隔
+const migrationRequeueInterval = 10 * time.Second
+
+// 可以互相迁移的常驻工作负载类型
+var migratableCategories = []string{
+	customv1.CategoryDeployment,
+	customv1.CategoryStatefulSet,
+	customv1.CategoryDaemonSet,
+}
+
+func newWorkload(category string) runtime.Object {
+	switch category {
+	case customv1.CategoryDeployment:
+		return &appsv1.Deployment{}
+	case customv1.CategoryStatefulSet:
+		return &appsv1.StatefulSet{}
+	case customv1.CategoryDaemonSet:
+		return &appsv1.DaemonSet{}
+	}
+	return nil
+}
+
+// spec.category 变更后，旧的工作负载不会被自动清理，且与新的工作负载选中同一组app label的pod。
+// 这里先等待新的工作负载就绪，再删除旧的工作负载，迁移进度记录在Unit.Status.Migration中。
+// 返回值 migrating 为true时表示迁移仍在进行中，需要稍后重新调谐
+func (r *UnitReconciler) migrateWorkload(instance *customv1.Unit) (migration *customv1.UnitMigrationStatus, migrating bool, err error) {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+
+	// 1. 找出Unit名下与当前category不一致的旧工作负载
+	var olds []runtime.Object
+	from := ""
+	for _, category := range migratableCategories {
+		if category == instance.Spec.Category {
+			continue
+		}
+		old := newWorkload(category)
+		if err := r.Get(ctx, key, old); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return instance.Status.Migration, false, err
+		}
+		if !metav1.IsControlledBy(old.(metav1.Object), instance) {
+			continue
+		}
+		olds = append(olds, old)
+		from = category
+	}
+
+	// 2. 没有旧的工作负载，若之前存在迁移记录，将其标记为完成
+	if len(olds) == 0 {
+		migration = instance.Status.Migration.DeepCopy()
+		if migration != nil && migration.Phase != customv1.MigrationPhaseCompleted {
+			migration.Phase = customv1.MigrationPhaseCompleted
+			migration.Message = fmt.Sprintf("%s %s/%s has been removed", migration.From, instance.Namespace, instance.Name)
+			migration.CompletionTime = metav1.Now()
+		}
+		return migration, false, nil
+	}
+
+	migration = &customv1.UnitMigrationStatus{
+		From:      from,
+		To:        instance.Spec.Category,
+		StartTime: metav1.Now(),
+	}
+	if last := instance.Status.Migration; last != nil && last.From == migration.From && last.To == migration.To &&
+		last.Phase != customv1.MigrationPhaseCompleted {
+		migration.StartTime = last.StartTime
+	}
+
+	// 3. 新的工作负载就绪之前，保留旧的工作负载继续提供服务
+	ready, err := r.workloadReady(instance)
+	if err != nil {
+		return instance.Status.Migration, false, err
+	}
+	if !ready {
+		migration.Phase = customv1.MigrationPhaseWaitingForReady
+		migration.Message = fmt.Sprintf("waiting for %s %s/%s to become ready", migration.To, instance.Namespace, instance.Name)
+		return migration, true, nil
+	}
+
+	// 4. 新的工作负载已就绪，删除旧的工作负载
+	for _, old := range olds {
+		msg := fmt.Sprintf("%s %s/%s is ready, delete the old %s", migration.To, instance.Namespace, instance.Name, migration.From)
+		r.Log.Info(msg)
+		if err := r.Delete(ctx, old, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "Failed to delete %s %s/%s, reason: %s, error: %v",
+				migration.From, instance.Namespace, instance.Name, errors.ReasonForError(err), err)
+			return instance.Status.Migration, false, err
+		}
+		r.recordEvent(instance, corev1.EventTypeNormal, customv1.EventReasonDeleted, "%s %s %s/%s, migrated to %s",
+			customv1.EventReasonDeleted, migration.From, instance.Namespace, instance.Name, migration.To)
+	}
+	migration.Phase = customv1.MigrationPhaseCompleted
+	migration.Message = fmt.Sprintf("%s %s/%s has been removed", migration.From, instance.Namespace, instance.Name)
+	migration.CompletionTime = metav1.Now()
+	return migration, false, nil
+}
+
+// 判断当前category对应的工作负载是否已就绪
+func (r *UnitReconciler) workloadReady(instance *customv1.Unit) (bool, error) {
+	workload := newWorkload(instance.Spec.Category)
+	if workload == nil {
+		return false, nil
+	}
+	err := r.Get(context.Background(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, workload)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	desired := int32(1)
+	if instance.Spec.Replicas != nil {
+		desired = *instance.Spec.Replicas
+	}
+
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		return w.Status.ObservedGeneration >= w.Generation &&
+			w.Status.UpdatedReplicas >= desired && w.Status.AvailableReplicas >= desired, nil
+	case *appsv1.StatefulSet:
+		return w.Status.ObservedGeneration >= w.Generation && w.Status.ReadyReplicas >= desired, nil
+	case *appsv1.DaemonSet:
+		return w.Status.ObservedGeneration >= w.Generation && w.Status.DesiredNumberScheduled > 0 &&
+			w.Status.NumberAvailable >= w.Status.DesiredNumberScheduled, nil
+	}
+	return false, nil
+}
+
+// 迁移完成后，清理旧工作负载残留在Unit.Status中的状态
+func resetWorkloadStatus(instance *customv1.Unit, category string) {
+	switch category {
+	case customv1.CategoryDeployment:
+		instance.Status.BaseDeployment = appsv1.DeploymentStatus{}
+	case customv1.CategoryStatefulSet:
+		instance.Status.BaseStatefulSet = appsv1.StatefulSetStatus{}
+	case customv1.CategoryDaemonSet:
+		instance.Status.BaseDaemonSet = appsv1.DaemonSetStatus{}
+	}
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"testing"
+	"time"
+
+	customv1 "Unit/api/v1"
+)
+
+func TestMigrateWorkload(t *testing.T) {
+	scheme := newInventoryTestScheme(t)
+	replicas := int32(2)
+	startTime := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+
+	newInstance := func(migration *customv1.UnitMigrationStatus) *customv1.Unit {
+		instance := &customv1.Unit{ObjectMeta: metav1.ObjectMeta{Name: "unit", Namespace: "default", UID: "unit-uid"}}
+		instance.Spec.Category = customv1.CategoryDeployment
+		instance.Spec.Replicas = &replicas
+		instance.Status.Migration = migration
+		return instance
+	}
+	objectMeta := func(instance *customv1.Unit, owned bool) metav1.ObjectMeta {
+		meta := metav1.ObjectMeta{Name: "unit", Namespace: "default", Generation: 1}
+		if owned {
+			if err := controllerutil.SetControllerReference(instance, &meta, scheme); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return meta
+	}
+	oldStatefulSet := func(instance *customv1.Unit, owned bool) runtime.Object {
+		return &appsv1.StatefulSet{ObjectMeta: objectMeta(instance, owned)}
+	}
+	newDeployment := func(instance *customv1.Unit, available int32) runtime.Object {
+		return &appsv1.Deployment{
+			ObjectMeta: objectMeta(instance, true),
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, UpdatedReplicas: available, AvailableReplicas: available},
+		}
+	}
+	waiting := &customv1.UnitMigrationStatus{From: customv1.CategoryStatefulSet, To: customv1.CategoryDeployment,
+		Phase: customv1.MigrationPhaseWaitingForReady, StartTime: startTime}
+
+	cases := []struct {
+		name          string
+		migration     *customv1.UnitMigrationStatus
+		objects       func(instance *customv1.Unit) []runtime.Object
+		wantPhase     string
+		wantMigrating bool
+		wantOldExists bool
+	}{
+		{
+			name:    "no old workload",
+			objects: func(instance *customv1.Unit) []runtime.Object { return []runtime.Object{newDeployment(instance, 2)} },
+		},
+		{
+			name: "old workload not owned by Unit",
+			objects: func(instance *customv1.Unit) []runtime.Object {
+				return []runtime.Object{oldStatefulSet(instance, false), newDeployment(instance, 0)}
+			},
+			wantOldExists: true,
+		},
+		{
+			name: "waiting for new workload",
+			objects: func(instance *customv1.Unit) []runtime.Object {
+				return []runtime.Object{oldStatefulSet(instance, true), newDeployment(instance, 1)}
+			},
+			wantPhase:     customv1.MigrationPhaseWaitingForReady,
+			wantMigrating: true,
+			wantOldExists: true,
+		},
+		{
+			name:      "new workload ready",
+			migration: waiting,
+			objects: func(instance *customv1.Unit) []runtime.Object {
+				return []runtime.Object{oldStatefulSet(instance, true), newDeployment(instance, 2)}
+			},
+			wantPhase: customv1.MigrationPhaseCompleted,
+		},
+		{
+			name:      "old workload removed outside of migration",
+			migration: waiting,
+			objects:   func(instance *customv1.Unit) []runtime.Object { return []runtime.Object{newDeployment(instance, 0)} },
+			wantPhase: customv1.MigrationPhaseCompleted,
+		},
+	}
+	for _, c := range cases {
+		instance := newInstance(c.migration.DeepCopy())
+		r := &UnitReconciler{
+			Client: fake.NewFakeClientWithScheme(scheme, c.objects(instance)...),
+			Log:    logf.Log.WithName("test"),
+			Scheme: scheme,
+		}
+
+		migration, migrating, err := r.migrateWorkload(instance)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if migrating != c.wantMigrating {
+			t.Errorf("%s: migrating = %v, want %v", c.name, migrating, c.wantMigrating)
+		}
+		if c.wantPhase == "" {
+			if migration != nil {
+				t.Errorf("%s: expected no migration, got %+v", c.name, migration)
+			}
+		} else if migration == nil || migration.Phase != c.wantPhase {
+			t.Errorf("%s: migration = %+v, want phase %s", c.name, migration, c.wantPhase)
+		} else if c.migration != nil && !migration.StartTime.Equal(&startTime) {
+			t.Errorf("%s: expected startTime %v to be kept, got %v", c.name, startTime, migration.StartTime)
+		}
+
+		err = r.Get(context.Background(), types.NamespacedName{Name: "unit", Namespace: "default"}, &appsv1.StatefulSet{})
+		if exists := err == nil; exists != c.wantOldExists {
+			if err != nil && !errors.IsNotFound(err) {
+				t.Errorf("%s: get StatefulSet error: %v", c.name, err)
+			}
+			t.Errorf("%s: StatefulSet exists = %v, want %v", c.name, exists, c.wantOldExists)
+		}
+	}
+}
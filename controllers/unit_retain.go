@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+
+	customv1 "Unit/api/v1"
+)
+
+// 等待删除前快照就绪的重新检查间隔
+const snapshotRequeueInterval = 5 * time.Second
+
+// Unit删除前按各PVC的retainPolicy处理PVC，返回waiting为true时表示还有快照未就绪，需要稍后重新检查。
+// 注意：foreground方式删除Unit时，gc与finalizer同时开始处理，PVC可能在这里处理之前就被删除，
+// retainPolicy只对默认的background删除有效，foreground删除时仍尽量处理还存在的PVC
+func (r *UnitReconciler) retainVolumes(instance *customv1.Unit) (waiting bool, err error) {
+	for _, volume := range unitVolumes(instance) {
+		if volume.RetainPolicy == "" || volume.RetainPolicy == customv1.PVCRetainPolicyDelete {
+			continue
+		}
+		if containsString(instance.Finalizers, metav1.FinalizerDeleteDependents) {
+			msg := fmt.Sprintf("Unit %s/%s is deleted in foreground, PVC of volume %s may be deleted before retainPolicy %s is applied",
+				instance.Namespace, instance.Name, volume.Name, volume.RetainPolicy)
+			r.Log.Info(msg)
+		}
+		claims, err := volume.ListClaims(instance, r.Client)
+		if err != nil {
+			msg := fmt.Sprintf("list PVC of Unit %s/%s error", instance.Namespace, instance.Name)
+			r.Log.Error(err, msg)
+			return false, err
+		}
+
+		for i := range claims {
+			claim := &claims[i]
+			switch volume.RetainPolicy {
+			case customv1.PVCRetainPolicyRetain:
+				if err := r.releaseClaim(instance, claim); err != nil {
+					return false, err
+				}
+			case customv1.PVCRetainPolicySnapshot:
+				ready, err := r.snapshotClaim(instance, claim, volume.SnapshotClassName)
+				if err != nil {
+					return false, err
+				}
+				if !ready {
+					waiting = true
+				}
+			}
+		}
+	}
+	return waiting, nil
+}
+
+// Unit的所有PVC声明，包括pvcInfo和volumes
+func unitVolumes(instance *customv1.Unit) []*customv1.OwnPVC {
+	var volumes []*customv1.OwnPVC
+	if instance.Spec.RelationResource.PVC != nil {
+		volumes = append(volumes, instance.Spec.RelationResource.PVC)
+	}
+	for i := range instance.Spec.RelationResource.Volumes {
+		volumes = append(volumes, &instance.Spec.RelationResource.Volumes[i])
+	}
+	return volumes
+}
+
+// 去掉PVC上Unit的ownerReference，Unit被回收后PVC保留
+func (r *UnitReconciler) releaseClaim(instance *customv1.Unit, claim *corev1.PersistentVolumeClaim) error {
+	var ownerReferences []metav1.OwnerReference
+	for _, ownerReference := range claim.OwnerReferences {
+		if ownerReference.UID != instance.UID {
+			ownerReferences = append(ownerReferences, ownerReference)
+		}
+	}
+	if len(ownerReferences) == len(claim.OwnerReferences) {
+		return nil
+	}
+
+	patched := claim.DeepCopy()
+	patched.OwnerReferences = ownerReferences
+	if err := r.Patch(context.TODO(), patched, client.MergeFrom(claim)); err != nil {
+		r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "Failed to retain PVC %s/%s, reason: %s, error: %v",
+			claim.Namespace, claim.Name, errors.ReasonForError(err), err)
+		return err
+	}
+	msg := fmt.Sprintf("PVC %s/%s is retained after Unit %s deleted", claim.Namespace, claim.Name, instance.Name)
+	r.Log.Info(msg)
+	r.recordEvent(instance, corev1.EventTypeNormal, "Retained", "Retained PVC %s/%s", claim.Namespace, claim.Name)
+	return nil
+}
+
+// 为PVC创建删除前的VolumeSnapshot，快照不属于Unit，Unit回收后保留。返回快照是否已就绪
+func (r *UnitReconciler) snapshotClaim(instance *customv1.Unit, claim *corev1.PersistentVolumeClaim,
+	snapshotClassName *string) (bool, error) {
+
+	if r.VolumeSnapshotAPIVersion == "" {
+		err := fmt.Errorf("VolumeSnapshot API is not served by the cluster, can not snapshot PVC %s/%s", claim.Namespace, claim.Name)
+		r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "%v", err)
+		return false, err
+	}
+
+	// 以删除时间作为后缀，重试时能找到同一个快照
+	name := fmt.Sprintf("%s-%d", claim.Name, instance.DeletionTimestamp.Unix())
+	found := &unstructured.Unstructured{}
+	found.SetAPIVersion(r.VolumeSnapshotAPIVersion)
+	found.SetKind(customv1.VolumeSnapshotKind)
+	err := r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: claim.Namespace}, found)
+	if errors.IsNotFound(err) {
+		snapshot := customv1.NewVolumeSnapshot(r.VolumeSnapshotAPIVersion, claim.Namespace, name, claim.Name,
+			snapshotClassName, map[string]string{customv1.UnitNameLabel: instance.Name})
+		if err := r.Create(context.TODO(), snapshot); err != nil {
+			r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "Failed to create VolumeSnapshot %s/%s, reason: %s, error: %v",
+				claim.Namespace, name, errors.ReasonForError(err), err)
+			return false, err
+		}
+		r.recordEvent(instance, corev1.EventTypeNormal, customv1.EventReasonCreated, "%s VolumeSnapshot %s/%s of PVC %s before deletion",
+			customv1.EventReasonCreated, claim.Namespace, name, claim.Name)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	ready, message := customv1.VolumeSnapshotReady(found)
+	if message != "" {
+		err := fmt.Errorf("VolumeSnapshot %s/%s failed: %s", claim.Namespace, name, message)
+		r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonFailed, "%v", err)
+		return false, err
+	}
+	if !ready {
+		msg := fmt.Sprintf("waiting for VolumeSnapshot %s/%s to be ready", claim.Namespace, name)
+		r.Log.Info(msg)
+	}
+	return ready, nil
+}
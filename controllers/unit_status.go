@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"reflect"
+	"strings"
+
+	customv1 "Unit/api/v1"
+)
+
+// 调谐单个own resource时出现的错误
+type ownResourceError struct {
+	// Deployment / StatefulSet / Service / Ingress / PersistentVolumeClaim ...
+	Kind string
+	// Apply / UpdateStatus
+	Action string
+	Err    error
+}
+
+// 类型名称是缩写的own resource，对应的资源Kind
+var ownResourceKinds = map[string]string{
+	"HPA":          "HorizontalPodAutoscaler",
+	"PDB":          "PodDisruptionBudget",
+	"PVC":          "PersistentVolumeClaim",
+	"GatewayRoute": customv1.HTTPRouteKind,
+}
+
+// own resource生成的资源Kind，与inventory中记录的Kind一致，例如 *customv1.OwnDeployment -> Deployment，*customv1.OwnHPA -> HorizontalPodAutoscaler
+func ownResourceKind(ownResource OwnResource) string {
+	name := strings.TrimPrefix(reflect.TypeOf(ownResource).Elem().Name(), "Own")
+	if kind, ok := ownResourceKinds[name]; ok {
+		return kind
+	}
+	return name
+}
+
+// 每一类own resource的条件，例如 DeploymentReady / ServiceReady
+func ownResourceConditionType(kind string) string {
+	return kind + "Ready"
+}
+
+// Available/Progressing/Degraded/ReconcileError/ClusterIPMismatch之外的条件都是<Kind>Ready
+func isOwnResourceConditionType(conditionType string) bool {
+	switch conditionType {
+	case customv1.ConditionAvailable, customv1.ConditionProgressing, customv1.ConditionDegraded, customv1.ConditionReconcileError,
+		customv1.ConditionClusterIPMismatch:
+		return false
+	}
+	return strings.HasSuffix(conditionType, "Ready")
+}
+
+// 根据各own resource的调谐结果和工作负载状态，计算Unit的conditions、observedGeneration和phase
+func updateUnitConditions(instance *customv1.Unit, ownResources []OwnResource, ownResourceErrors []ownResourceError) {
+	generation := instance.Generation
+	status := &instance.Status
+	status.ObservedGeneration = generation
+
+	newCondition := func(conditionType string, value bool, reason, message string) customv1.UnitCondition {
+		conditionStatus := corev1.ConditionFalse
+		if value {
+			conditionStatus = corev1.ConditionTrue
+		}
+		return customv1.UnitCondition{
+			Type:               conditionType,
+			Status:             conditionStatus,
+			ObservedGeneration: generation,
+			Reason:             reason,
+			Message:            message,
+		}
+	}
+
+	// 1. 每一类own resource一个<Kind>Ready条件，同一类的多个资源(例如多个Service)出错时合并到一个条件中。
+	// 调谐过程中的其它步骤(清理、迁移、备份)出错时，也以出错的Kind记录
+	var kinds []string
+	failures := make(map[string][]ownResourceError)
+	for _, ownResource := range ownResources {
+		kind := ownResourceKind(ownResource)
+		if _, ok := failures[kind]; !ok {
+			failures[kind] = nil
+			kinds = append(kinds, kind)
+		}
+	}
+	for _, e := range ownResourceErrors {
+		if _, ok := failures[e.Kind]; !ok {
+			kinds = append(kinds, e.Kind)
+		}
+		failures[e.Kind] = append(failures[e.Kind], e)
+	}
+
+	readyTypes := make(map[string]bool)
+	var failedKinds []string
+	for _, kind := range kinds {
+		conditionType := ownResourceConditionType(kind)
+		readyTypes[conditionType] = true
+		errs := failures[kind]
+		if len(errs) == 0 {
+			status.SetCondition(newCondition(conditionType, true, "Reconciled", fmt.Sprintf("%s is reconciled", kind)))
+			continue
+		}
+		var messages []string
+		for _, e := range errs {
+			messages = append(messages, fmt.Sprintf("%s: %v", e.Action, e.Err))
+		}
+		failedKinds = append(failedKinds, kind)
+		status.SetCondition(newCondition(conditionType, false, errs[0].Action+"Failed", strings.Join(messages, "; ")))
+	}
+	// 已经不再由Unit管理的资源类型，去掉它的条件
+	for _, condition := range append([]customv1.UnitCondition(nil), status.Conditions...) {
+		if isOwnResourceConditionType(condition.Type) && !readyTypes[condition.Type] {
+			status.RemoveCondition(condition.Type)
+		}
+	}
+
+	// ReconcileError汇总出错的资源类型，详细的错误在各自的<Kind>Ready条件中
+	if len(failedKinds) > 0 {
+		message := strings.Join(failedKinds, ", ") + " failed to reconcile, see the <Kind>Ready conditions for details"
+		status.SetCondition(newCondition(customv1.ConditionReconcileError, true, "OwnResourceFailed", message))
+	} else {
+		status.SetCondition(newCondition(customv1.ConditionReconcileError, false, "ReconcileSuccess", "all own resources are reconciled"))
+	}
+
+	// 2. Available / Progressing / Degraded，由当前category对应的工作负载状态决定
+	available, progressing, failed, reason, message := workloadHealth(instance)
+	if migration := status.Migration; migration != nil && migration.Phase == customv1.MigrationPhaseWaitingForReady {
+		progressing = true
+		reason, message = "Migrating", migration.Message
+	}
+	// 工作负载尚未创建时视为Pending，不算作Degraded
+	pending := reason == "NotFound"
+	degraded := failed || (!available && !progressing && !pending) || len(ownResourceErrors) > 0
+	status.SetCondition(newCondition(customv1.ConditionAvailable, available, reason, message))
+	status.SetCondition(newCondition(customv1.ConditionProgressing, progressing, reason, message))
+	if len(ownResourceErrors) > 0 && !failed {
+		reason, message = "ReconcileError", status.GetCondition(customv1.ConditionReconcileError).Message
+	}
+	status.SetCondition(newCondition(customv1.ConditionDegraded, degraded, reason, message))
+
+	// 3. phase
+	switch {
+	case failed:
+		status.Phase = customv1.UnitPhaseFailed
+	case degraded:
+		status.Phase = customv1.UnitPhaseDegraded
+	case pending:
+		status.Phase = customv1.UnitPhasePending
+	case progressing:
+		status.Phase = customv1.UnitPhaseProgressing
+	case available && instance.Spec.Category == customv1.CategoryJob:
+		status.Phase = customv1.UnitPhaseSucceeded
+	case available:
+		status.Phase = customv1.UnitPhaseRunning
+	default:
+		status.Phase = customv1.UnitPhasePending
+	}
+}
+
+// 按Service status检查已有Service的clusterIP是否与期望一致，不一致时设置ClusterIPMismatch条件，恢复一致后去掉条件。
+// 只在条件第一次出现或不一致的内容变化时记录Warning Event，避免每次调谐重复记录
+func (r *UnitReconciler) updateClusterIPMismatch(instance *customv1.Unit, ownResources []OwnResource) {
+	status := &instance.Status
+	var mismatches []string
+	for _, ownResource := range ownResources {
+		ownService, ok := ownResource.(*customv1.OwnService)
+		if !ok {
+			continue
+		}
+		serviceStatus := &status.RelationResourceStatus.Service
+		if ownService.Name != "" {
+			serviceStatus = nil
+			for i := range status.RelationResourceStatus.Services {
+				if status.RelationResourceStatus.Services[i].Name == ownService.Name {
+					serviceStatus = &status.RelationResourceStatus.Services[i]
+				}
+			}
+		}
+		expected := ownService.ExpectedClusterIP(instance)
+		if expected == "" || serviceStatus == nil || serviceStatus.ClusterIP == "" || serviceStatus.ClusterIP == expected {
+			continue
+		}
+		mismatches = append(mismatches, fmt.Sprintf("Service %s/%s clusterIP is %s but %s is expected",
+			instance.Namespace, ownService.ServiceName(instance), serviceStatus.ClusterIP, expected))
+	}
+
+	if len(mismatches) == 0 {
+		status.RemoveCondition(customv1.ConditionClusterIPMismatch)
+		return
+	}
+	message := strings.Join(mismatches, "; ") + ", set allowRecreate to recreate the Service"
+	if condition := status.GetCondition(customv1.ConditionClusterIPMismatch); condition == nil || condition.Message != message {
+		r.recordEvent(instance, corev1.EventTypeWarning, customv1.EventReasonClusterIPMismatch, "%s", message)
+	}
+	status.SetCondition(customv1.UnitCondition{
+		Type:               customv1.ConditionClusterIPMismatch,
+		Status:             corev1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             customv1.EventReasonClusterIPMismatch,
+		Message:            message,
+	})
+}
+
+// 判断当前category对应的工作负载的健康状况
+func workloadHealth(instance *customv1.Unit) (available, progressing, failed bool, reason, message string) {
+	status := &instance.Status
+	desired := int32(1)
+	if instance.Spec.Replicas != nil {
+		desired = *instance.Spec.Replicas
+	}
+
+	switch instance.Spec.Category {
+	case customv1.CategoryDeployment:
+		s := status.BaseDeployment
+		available = s.AvailableReplicas >= desired
+		progressing = s.UpdatedReplicas < desired || s.Replicas > s.UpdatedReplicas
+		message = fmt.Sprintf("%d/%d replicas available, %d updated", s.AvailableReplicas, desired, s.UpdatedReplicas)
+
+	case customv1.CategoryDaemonSet:
+		s := status.BaseDaemonSet
+		available = s.NumberAvailable >= s.DesiredNumberScheduled
+		progressing = s.UpdatedNumberScheduled < s.DesiredNumberScheduled
+		message = fmt.Sprintf("%d/%d pods available, %d updated", s.NumberAvailable, s.DesiredNumberScheduled, s.UpdatedNumberScheduled)
+
+	case customv1.CategoryJob:
+		if len(status.JobHistory) == 0 {
+			return false, false, false, "NotFound", "Job has not been created"
+		}
+		job := status.JobHistory[0]
+		available = job.Phase == customv1.JobPhaseSucceeded
+		progressing = job.Phase == customv1.JobPhaseRunning
+		failed = job.Phase == customv1.JobPhaseFailed
+		message = fmt.Sprintf("Job %s is %s", job.Name, job.Phase)
+		return available, progressing, failed, "Job" + job.Phase, message
+
+	case customv1.CategoryCronJob:
+		// CronJob 只要在按周期调度即视为可用，最近一次执行失败则视为不可用，Unit进入Degraded
+		progressing = len(status.BaseCronJob.Active) > 0
+		if len(status.JobHistory) > 0 && status.JobHistory[0].Phase == customv1.JobPhaseFailed {
+			message = fmt.Sprintf("last Job %s is %s", status.JobHistory[0].Name, status.JobHistory[0].Phase)
+			return false, progressing, false, "LastJobFailed", message
+		}
+		return true, progressing, false, "CronJobScheduling", "CronJob is scheduling"
+
+	default:
+		s := status.BaseStatefulSet
+		available = s.ReadyReplicas >= desired
+		progressing = s.UpdatedReplicas < desired || s.CurrentRevision != s.UpdateRevision
+		message = fmt.Sprintf("%d/%d replicas ready, %d updated", s.ReadyReplicas, desired, s.UpdatedReplicas)
+	}
+
+	switch {
+	case progressing:
+		reason = "Updating"
+	case available:
+		reason = "ReplicasAvailable"
+	default:
+		reason = "ReplicasUnavailable"
+	}
+	return available, progressing, failed, reason, message
+}
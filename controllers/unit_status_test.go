@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	customv1 "Unit/api/v1"
+)
+
+func newStatusTestUnit(category string, replicas int32) *customv1.Unit {
+	return &customv1.Unit{
+		ObjectMeta: metav1.ObjectMeta{Name: "unit-status", Namespace: "default", Generation: 3},
+		Spec:       customv1.UnitSpec{Category: category, Replicas: &replicas},
+	}
+}
+
+func TestWorkloadHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		unit        func() *customv1.Unit
+		available   bool
+		progressing bool
+		failed      bool
+		reason      string
+	}{
+		{
+			name: "deployment available",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryDeployment, 2)
+				unit.Status.BaseDeployment = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+				return unit
+			},
+			available: true, reason: "ReplicasAvailable",
+		},
+		{
+			name: "deployment rolling update",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryDeployment, 2)
+				unit.Status.BaseDeployment = appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2}
+				return unit
+			},
+			available: true, progressing: true, reason: "Updating",
+		},
+		{
+			name: "deployment unavailable",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryDeployment, 2)
+				unit.Status.BaseDeployment = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1}
+				return unit
+			},
+			reason: "ReplicasUnavailable",
+		},
+		{
+			name: "statefulSet revision not rolled out",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryStatefulSet, 1)
+				unit.Status.BaseStatefulSet = appsv1.StatefulSetStatus{ReadyReplicas: 1, UpdatedReplicas: 1,
+					CurrentRevision: "v1", UpdateRevision: "v2"}
+				return unit
+			},
+			available: true, progressing: true, reason: "Updating",
+		},
+		{
+			name: "daemonSet available",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryDaemonSet, 0)
+				unit.Status.BaseDaemonSet = appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberAvailable: 3, UpdatedNumberScheduled: 3}
+				return unit
+			},
+			available: true, reason: "ReplicasAvailable",
+		},
+		{
+			name:   "job not created",
+			unit:   func() *customv1.Unit { return newStatusTestUnit(customv1.CategoryJob, 0) },
+			reason: "NotFound",
+		},
+		{
+			name: "job running",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryJob, 0)
+				unit.Status.JobHistory = []customv1.UnitJobStatus{{Name: "unit-status", Phase: customv1.JobPhaseRunning}}
+				return unit
+			},
+			progressing: true, reason: "JobRunning",
+		},
+		{
+			name: "job failed",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryJob, 0)
+				unit.Status.JobHistory = []customv1.UnitJobStatus{{Name: "unit-status", Phase: customv1.JobPhaseFailed}}
+				return unit
+			},
+			failed: true, reason: "JobFailed",
+		},
+		{
+			name: "cronJob last job failed",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryCronJob, 0)
+				unit.Status.JobHistory = []customv1.UnitJobStatus{{Name: "unit-status-1", Phase: customv1.JobPhaseFailed}}
+				return unit
+			},
+			reason: "LastJobFailed",
+		},
+		{
+			name: "cronJob scheduling with an active job",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryCronJob, 0)
+				unit.Status.BaseCronJob = batchv1beta1.CronJobStatus{Active: []corev1.ObjectReference{{Name: "unit-status-1"}}}
+				return unit
+			},
+			available: true, progressing: true, reason: "CronJobScheduling",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, progressing, failed, reason, _ := workloadHealth(tt.unit())
+			if available != tt.available || progressing != tt.progressing || failed != tt.failed || reason != tt.reason {
+				t.Errorf("workloadHealth() = (%v, %v, %v, %q), want (%v, %v, %v, %q)",
+					available, progressing, failed, reason, tt.available, tt.progressing, tt.failed, tt.reason)
+			}
+		})
+	}
+}
+
+func TestUpdateUnitConditions(t *testing.T) {
+	availableDeployment := func() *customv1.Unit {
+		unit := newStatusTestUnit(customv1.CategoryDeployment, 1)
+		unit.Status.BaseDeployment = appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+		return unit
+	}
+	ownResources := []OwnResource{&customv1.OwnDeployment{}, &customv1.OwnService{}, &customv1.OwnService{Name: "admin"}}
+
+	tests := []struct {
+		name             string
+		unit             func() *customv1.Unit
+		errors           []ownResourceError
+		phase            string
+		conditions       map[string]corev1.ConditionStatus
+		reasons          map[string]string
+		messages         map[string]string
+		absentConditions []string
+	}{
+		{
+			name:  "all own resources reconciled",
+			unit:  availableDeployment,
+			phase: customv1.UnitPhaseRunning,
+			conditions: map[string]corev1.ConditionStatus{
+				customv1.ConditionAvailable:      corev1.ConditionTrue,
+				customv1.ConditionProgressing:    corev1.ConditionFalse,
+				customv1.ConditionDegraded:       corev1.ConditionFalse,
+				customv1.ConditionReconcileError: corev1.ConditionFalse,
+				"DeploymentReady":                corev1.ConditionTrue,
+				"ServiceReady":                   corev1.ConditionTrue,
+			},
+		},
+		{
+			name: "one condition per failing kind",
+			unit: availableDeployment,
+			errors: []ownResourceError{
+				{Kind: "Service", Action: "Apply", Err: errors.New("port conflict")},
+				{Kind: "Service", Action: "UpdateStatus", Err: errors.New("not found")},
+				{Kind: customv1.VolumeSnapshotKind, Action: "Backup", Err: errors.New("no snapshot class")},
+			},
+			phase: customv1.UnitPhaseDegraded,
+			conditions: map[string]corev1.ConditionStatus{
+				customv1.ConditionAvailable:      corev1.ConditionTrue,
+				customv1.ConditionDegraded:       corev1.ConditionTrue,
+				customv1.ConditionReconcileError: corev1.ConditionTrue,
+				"DeploymentReady":                corev1.ConditionTrue,
+				"ServiceReady":                   corev1.ConditionFalse,
+				"VolumeSnapshotReady":            corev1.ConditionFalse,
+			},
+			reasons: map[string]string{
+				"ServiceReady":                   "ApplyFailed",
+				"VolumeSnapshotReady":            "BackupFailed",
+				customv1.ConditionReconcileError: "OwnResourceFailed",
+			},
+			messages: map[string]string{
+				"ServiceReady": "Apply: port conflict; UpdateStatus: not found",
+			},
+		},
+		{
+			name: "conditions of kinds no longer owned are removed",
+			unit: func() *customv1.Unit {
+				unit := availableDeployment()
+				unit.Status.Conditions = []customv1.UnitCondition{
+					{Type: "IngressReady", Status: corev1.ConditionFalse, Reason: "ApplyFailed"},
+				}
+				return unit
+			},
+			phase:            customv1.UnitPhaseRunning,
+			conditions:       map[string]corev1.ConditionStatus{"DeploymentReady": corev1.ConditionTrue},
+			absentConditions: []string{"IngressReady"},
+		},
+		{
+			name: "migration in progress",
+			unit: func() *customv1.Unit {
+				unit := newStatusTestUnit(customv1.CategoryDeployment, 1)
+				unit.Status.Migration = &customv1.UnitMigrationStatus{From: customv1.CategoryStatefulSet,
+					To: customv1.CategoryDeployment, Phase: customv1.MigrationPhaseWaitingForReady}
+				return unit
+			},
+			phase: customv1.UnitPhaseProgressing,
+			conditions: map[string]corev1.ConditionStatus{
+				customv1.ConditionProgressing: corev1.ConditionTrue,
+				customv1.ConditionDegraded:    corev1.ConditionFalse,
+			},
+			reasons: map[string]string{customv1.ConditionProgressing: "Migrating"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := tt.unit()
+			updateUnitConditions(unit, ownResources, tt.errors)
+
+			if unit.Status.Phase != tt.phase {
+				t.Errorf("phase = %s, want %s", unit.Status.Phase, tt.phase)
+			}
+			if unit.Status.ObservedGeneration != unit.Generation {
+				t.Errorf("observedGeneration = %d, want %d", unit.Status.ObservedGeneration, unit.Generation)
+			}
+			for conditionType, want := range tt.conditions {
+				condition := unit.Status.GetCondition(conditionType)
+				if condition == nil {
+					t.Errorf("condition %s not found", conditionType)
+					continue
+				}
+				if condition.Status != want {
+					t.Errorf("condition %s = %s, want %s", conditionType, condition.Status, want)
+				}
+			}
+			for conditionType, want := range tt.reasons {
+				if condition := unit.Status.GetCondition(conditionType); condition == nil || condition.Reason != want {
+					t.Errorf("condition %s reason = %v, want %s", conditionType, condition, want)
+				}
+			}
+			for conditionType, want := range tt.messages {
+				if condition := unit.Status.GetCondition(conditionType); condition == nil || condition.Message != want {
+					t.Errorf("condition %s message = %v, want %s", conditionType, condition, want)
+				}
+			}
+			for _, conditionType := range tt.absentConditions {
+				if unit.Status.GetCondition(conditionType) != nil {
+					t.Errorf("condition %s should be removed", conditionType)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateClusterIPMismatch(t *testing.T) {
+	unit := newStatusTestUnit(customv1.CategoryDeployment, 1)
+	unit.Name = "unit"
+	ownResources := []OwnResource{
+		&customv1.OwnService{ClusterIP: "10.0.0.10"},
+		&customv1.OwnService{Name: "admin", ClusterIP: "10.0.0.11"},
+		&customv1.OwnService{Name: "metrics"},
+	}
+	unit.Status.RelationResourceStatus.Service = customv1.UnitRelationServiceStatus{ClusterIP: "10.0.0.10"}
+	unit.Status.RelationResourceStatus.Services = []customv1.UnitRelationServiceStatus{
+		{Name: "admin", ClusterIP: "10.0.0.20"},
+		{Name: "metrics", ClusterIP: "10.0.0.30"},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &UnitReconciler{Recorder: recorder}
+
+	// 不一致第一次出现时记录Event，之后的调谐只保留条件
+	for i := 0; i < 2; i++ {
+		r.updateClusterIPMismatch(unit, ownResources)
+		condition := unit.Status.GetCondition(customv1.ConditionClusterIPMismatch)
+		if condition == nil || condition.Status != corev1.ConditionTrue || !strings.Contains(condition.Message, "default/unit-admin") ||
+			strings.Contains(condition.Message, "default/unit ") || strings.Contains(condition.Message, "metrics") {
+			t.Fatalf("unexpected ClusterIPMismatch condition %+v", condition)
+		}
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected 1 ClusterIPMismatch event, got %d", len(recorder.Events))
+	}
+
+	// Service重建后条件去掉
+	unit.Status.RelationResourceStatus.Services[0].ClusterIP = "10.0.0.11"
+	r.updateClusterIPMismatch(unit, ownResources)
+	if condition := unit.Status.GetCondition(customv1.ConditionClusterIPMismatch); condition != nil {
+		t.Errorf("expected ClusterIPMismatch condition to be removed, got %+v", condition)
+	}
+}
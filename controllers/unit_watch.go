@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	customv1 "Unit/api/v1"
+)
+
+// 过滤掉不会影响Unit调谐结果的事件
+var unitEventFilter = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		// informer周期性resync产生的事件，对象本身并未变化
+		if e.MetaOld.GetResourceVersion() == e.MetaNew.GetResourceVersion() {
+			return false
+		}
+
+		switch newObj := e.ObjectNew.(type) {
+		case *customv1.Unit:
+			// Unit.status由controller自身更新，忽略status-only的变更，避免自己触发自己的调谐
+			return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() ||
+				!reflect.DeepEqual(e.MetaOld.GetLabels(), e.MetaNew.GetLabels()) ||
+				!reflect.DeepEqual(e.MetaOld.GetFinalizers(), e.MetaNew.GetFinalizers()) ||
+				!e.MetaNew.GetDeletionTimestamp().Equal(e.MetaOld.GetDeletionTimestamp())
+
+		case *corev1.Service:
+			// LoadBalancer分配的ingress IP需要同步到Unit.status
+			oldObj := e.ObjectOld.(*corev1.Service)
+			return !reflect.DeepEqual(oldObj.Spec, newObj.Spec) ||
+				!reflect.DeepEqual(oldObj.Status.LoadBalancer, newObj.Status.LoadBalancer) ||
+				!reflect.DeepEqual(oldObj.Labels, newObj.Labels)
+
+		case *corev1.Endpoints:
+			// leader election等会频繁更新Endpoints的annotations，只关心subsets的变化
+			oldObj := e.ObjectOld.(*corev1.Endpoints)
+			return !reflect.DeepEqual(oldObj.Subsets, newObj.Subsets)
+
+		case *autoscalingv2beta2.HorizontalPodAutoscaler:
+			// HPA每个同步周期都会刷新status中的metrics，只在spec变化时触发调谐，status随下次调谐一并更新
+			oldObj := e.ObjectOld.(*autoscalingv2beta2.HorizontalPodAutoscaler)
+			return !reflect.DeepEqual(oldObj.Spec, newObj.Spec) ||
+				!reflect.DeepEqual(oldObj.Labels, newObj.Labels)
+
+		case *unstructured.Unstructured:
+			// Ingress按集群的API版本以unstructured对象watch，Unit只关心它的spec，status(loadBalancer)的变化忽略。
+			// EndpointSlice的endpoints变化需要同步到Unit.status；HTTPRoute的status中有Gateway给出的Accepted/ResolvedRefs，需要同步到Unit.status，不做过滤
+			if newObj.GetKind() != "Ingress" {
+				return true
+			}
+			oldObj := e.ObjectOld.(*unstructured.Unstructured)
+			return !reflect.DeepEqual(oldObj.Object["spec"], newObj.Object["spec"]) ||
+				!reflect.DeepEqual(oldObj.GetLabels(), newObj.GetLabels())
+		}
+
+		// Deployment/StatefulSet/DaemonSet/Job/CronJob/PVC 的status变化需要同步到Unit.status，不做过滤
+		return true
+	},
+}
+
+// Endpoints由endpoints controller根据Service自动维护，没有ownerReference，
+// 这里通过同名的Service找到它所属的Unit。集群不提供EndpointSlice时才watch Endpoints
+func (r *UnitReconciler) endpointsToUnit(obj handler.MapObject) []reconcile.Request {
+	return r.serviceToUnit(obj.Meta.GetNamespace(), obj.Meta.GetName())
+}
+
+// EndpointSlice通过kubernetes.io/service-name label关联到Service，再通过Service找到它所属的Unit
+func (r *UnitReconciler) endpointSliceToUnit(obj handler.MapObject) []reconcile.Request {
+	serviceName := obj.Meta.GetLabels()[discoveryv1beta1.LabelServiceName]
+	if serviceName == "" {
+		return nil
+	}
+	return r.serviceToUnit(obj.Meta.GetNamespace(), serviceName)
+}
+
+// 从缓存中获取Service，返回它的controller Unit
+func (r *UnitReconciler) serviceToUnit(namespace, name string) []reconcile.Request {
+	service := &corev1.Service{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: namespace}, service); err != nil {
+		return nil
+	}
+
+	owner := metav1.GetControllerOf(service)
+	if owner == nil || owner.Kind != "Unit" || owner.APIVersion != customv1.GroupVersion.String() {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: owner.Name, Namespace: namespace}},
+	}
+}
+
+// 通过discovery探测集群提供的Ingress API版本，优先使用networking.k8s.io/v1
+func detectIngressAPIVersion(config *rest.Config) (string, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return "", err
+	}
+
+	apiVersions := []string{
+		customv1.IngressAPIVersionNetworkingV1,
+		customv1.IngressAPIVersionNetworkingV1beta1,
+		customv1.IngressAPIVersionExtensionsV1beta1,
+	}
+	for _, apiVersion := range apiVersions {
+		served, err := resourceServedBy(discoveryClient, apiVersion, "ingresses")
+		if err != nil {
+			return "", err
+		}
+		if served {
+			return apiVersion, nil
+		}
+	}
+	return "", fmt.Errorf("none of Ingress API %v is served by the cluster", apiVersions)
+}
+
+// 判断集群是否提供了某个API资源，例如可选安装的Gateway API
+func resourceServed(config *rest.Config, apiVersion, resource string) (bool, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return false, err
+	}
+	return resourceServedBy(discoveryClient, apiVersion, resource)
+}
+
+func resourceServedBy(discoveryClient discovery.DiscoveryInterface, apiVersion, resource string) (bool, error) {
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, apiResource := range resources.APIResources {
+		if apiResource.Name == resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}